@@ -0,0 +1,336 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+	"github.com/abperiasamy/pinata/uci"
+	"github.com/chzyer/readline"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+const (
+	gMaxEvalCP     = 1000 // Evaluations are capped, so a lost position can't lose much more.
+	gInaccuracyCP  = 50   // Centipawn loss of an inaccuracy, mistake and blunder.
+	gMistakeCP     = 100
+	gBlunderCP     = 300
+	gBetterLinePly = 8 // Plies of the engine's better line written as a variation.
+)
+
+// analyzeCmd annotates a game with the local engine
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze <game.pgn|id>",
+	Short: "Analyze a game with the local engine and save it annotated.",
+	Long: `Every move is checked by the engine. Inaccuracies, mistakes and blunders are
+marked with ?!, ? and ?? by the centipawns lost, every move gets an [%eval]
+comment, and the engine's better line is added as a variation. Each side gets
+an accuracy score from 0 to 100.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		onStart()
+		filename := findGameFile(args[0])
+		game, notes, err := readPGN(filename)
+		if err != nil {
+			fmt.Println(filename, "is not a valid PGN file,", err)
+			os.Exit(1)
+		}
+		notation, err := parseNotation(gNotation)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		gNotation = notation
+
+		eng, err := newEngine(gEngineBinary)
+		if err != nil {
+			exitEngineError(err)
+		}
+		defer eng.Close()
+		eng.SendOption("Threads", "8")
+
+		output := gAnalysisOutput
+		if output == "" {
+			output = analysisFile(filename)
+		}
+		if err := analyzeGame(eng, game, notes, output); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(analyzeCmd)
+
+	analyzeCmd.Flags().StringVarP(&gAnalysisOutput, "output", "o", "", "write the annotated PGN to this file (default analysis next to the config file)")
+}
+
+// Annotated PGN file of a game, named after the game in the analysis directory.
+func analysisFile(filename string) string {
+	return filepath.Join(filepath.Dir(defaultConfigPath()), "analysis", gameID(filename)+".pgn")
+}
+
+// Engine's view of a position.
+type positionEval struct {
+	cp   int      // Capped evaluation from the side to move's point of view.
+	eval string   // Evaluation from white's point of view, as in [%eval].
+	line []string // Best line in long algebraic notation.
+}
+
+// Evaluate the position with the engine, unless the game is decided on the board.
+func evalPosition(engine *uci.Engine, pos *chess.Position) (positionEval, error) {
+	switch pos.Status() {
+	case chess.Checkmate:
+		if pos.Turn() == chess.White {
+			return positionEval{cp: -gMaxEvalCP, eval: "#-0"}, nil
+		}
+		return positionEval{cp: -gMaxEvalCP, eval: "#0"}, nil
+	case chess.Stalemate, chess.InsufficientMaterial:
+		return positionEval{eval: "0.00"}, nil
+	}
+
+	engine.SetFEN(pos.String())
+	results, err := engine.Go(gSearchLimits, uci.HighestDepthOnly)
+	if err != nil {
+		return positionEval{}, err
+	}
+	top := topMoves(results)
+	if len(top) == 0 { // Engine did not report any lines, take its move at face value.
+		return positionEval{eval: "0.00", line: []string{results.BestMove}}, nil
+	}
+	r := top[0]
+
+	e := positionEval{line: r.BestMoves}
+	sign := 1
+	if pos.Turn() == chess.Black {
+		sign = -1
+	}
+	if r.Mate {
+		e.cp = gMaxEvalCP
+		if r.Score <= 0 {
+			e.cp = -gMaxEvalCP
+		}
+		e.eval = "#" + strconv.Itoa(sign*r.Score)
+	} else {
+		e.cp = r.Score
+		if e.cp > gMaxEvalCP {
+			e.cp = gMaxEvalCP
+		} else if e.cp < -gMaxEvalCP {
+			e.cp = -gMaxEvalCP
+		}
+		e.eval = fmt.Sprintf("%.2f", float64(sign*r.Score)/100)
+	}
+	return e, nil
+}
+
+// Winning chances in percent for an evaluation, as lichess.org estimates them.
+func winPercent(cp int) float64 {
+	return 50 + 50*(2/(1+math.Exp(-0.00368208*float64(cp)))-1)
+}
+
+// Accuracy of a move from the winning chances it lost, 0 to 100.
+func moveAccuracy(before, after float64) float64 {
+	accuracy := 103.1668*math.Exp(-0.04354*(before-after)) - 3.1669
+	return math.Max(0, math.Min(100, accuracy))
+}
+
+// Engine's line from the position as a variation in SAN.
+func betterLine(pos *chess.Position, line []string) []*pgnMove {
+	var moves []*pgnMove
+	for i, lan := range line {
+		if i == gBetterLinePly {
+			break
+		}
+		var move *chess.Move // Valid moves carry the check tags of SAN.
+		for _, m := range pos.ValidMoves() {
+			if chess.Encoder.Encode(chess.LongAlgebraicNotation{}, pos, m) == lan {
+				move = m
+			}
+		}
+		if move == nil {
+			break
+		}
+		moves = append(moves, &pgnMove{san: chess.Encoder.Encode(chess.AlgebraicNotation{}, pos, move)})
+		pos = pos.Update(move)
+	}
+	return moves
+}
+
+// Whether the move already has the variation, from an earlier analysis.
+func hasVariation(note pgnMove, line []*pgnMove) bool {
+	for _, n := range note.after {
+		if len(n.variation) != len(line) {
+			continue
+		}
+		same := true
+		for i, move := range n.variation {
+			same = same && move.san == line[i].san
+		}
+		if same {
+			return true
+		}
+	}
+	return false
+}
+
+// Replace the move's mark, the NAGs from $1 (!) to $6 (?!), with the NAG
+// given as a suffix, none if 0.
+func setMoveMark(note pgnMove, nag int) pgnMove {
+	var after []pgnNote
+	if nag > 0 {
+		after = append(after, pgnNote{nag: nag, suffix: true})
+	}
+	for _, n := range note.after {
+		if n.variation == nil && n.comment == "" && n.nag >= 1 && n.nag <= 6 {
+			continue
+		}
+		after = append(after, n)
+	}
+	note.after = after
+	return note
+}
+
+// Accuracy and errors of one side.
+type sideAnalysis struct {
+	moves                            int
+	accuracy                         float64 // Sum of the move accuracies.
+	loss                             int     // Sum of the centipawns lost.
+	inaccuracies, mistakes, blunders int
+}
+
+// Analyze every ply of the game, print the errors and the accuracy of both
+// sides, and write the game annotated to the file. The notes of the plies
+// are kept, their evaluations and move marks replaced.
+func analyzeGame(engine *uci.Engine, game *chess.Game, notes []pgnMove, filename string) error {
+	positions := game.Positions()
+	moves := game.Moves()
+	fmt.Println("Analyzing", len(moves), "plies with", gConsole.Bold(gConsole.Yellow(engine.Name)), "at", limitsString(gSearchLimits))
+	evals := make([]positionEval, len(positions))
+	for i, pos := range positions {
+		e, err := evalPosition(engine, pos)
+		if err != nil {
+			return err
+		}
+		evals[i] = e
+	}
+
+	sides := map[chess.Color]*sideAnalysis{chess.White: {}, chess.Black: {}}
+	notes = append(make([]pgnMove, 0, len(moves)), notes...)
+	for len(notes) < len(moves) {
+		notes = append(notes, pgnMove{})
+	}
+	for i, move := range moves {
+		pos, before, after := positions[i], evals[i], evals[i+1]
+		side := sides[pos.Turn()]
+		side.moves++
+
+		// Losses are seen from the mover's side, the next position is evaluated for the opponent.
+		loss := before.cp + after.cp
+		played := chess.Encoder.Encode(chess.LongAlgebraicNotation{}, pos, move)
+		if loss < 0 || (len(before.line) > 0 && before.line[0] == played) {
+			loss = 0
+		}
+		side.loss += loss
+		side.accuracy += moveAccuracy(winPercent(before.cp), winPercent(-after.cp))
+
+		var kind string
+		var nag int
+		switch {
+		case loss >= gBlunderCP:
+			kind, nag = "blunder", 4
+			side.blunders++
+		case loss >= gMistakeCP:
+			kind, nag = "mistake", 2
+			side.mistakes++
+		case loss >= gInaccuracyCP:
+			kind, nag = "inaccuracy", 6
+			side.inaccuracies++
+		}
+		notes[i] = setMoveMark(setCommand(notes[i], "eval", "[%eval "+after.eval+"]"), nag)
+		if kind == "" {
+			continue
+		}
+		if line := betterLine(pos, before.line); len(line) > 0 && !hasVariation(notes[i], line) {
+			notes[i].after = append(notes[i].after, pgnNote{variation: line})
+		}
+
+		moveNum := strconv.Itoa(fullMoveNumber(pos)) + "."
+		if pos.Turn() == chess.Black {
+			moveNum += ".."
+		}
+		best := "-"
+		if len(before.line) > 0 {
+			best = lanToNotation(pos, before.line[0])
+		}
+		fmt.Println(moveNum, gConsole.Bold(gConsole.Yellow(formatMove(pos, move))), kind+",",
+			"lost", loss, "centipawns, best was", gConsole.Bold(gConsole.Yellow(best)))
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Side", "Player", "Accuracy", "Avg Loss", "Inaccuracies", "Mistakes", "Blunders"})
+	for _, color := range []chess.Color{chess.White, chess.Black} {
+		side := sides[color]
+		accuracy, avgLoss := "-", "-"
+		if side.moves > 0 {
+			accuracy = strconv.Itoa(int(side.accuracy/float64(side.moves)+0.5)) + "%"
+			avgLoss = strconv.Itoa(side.loss / side.moves)
+			game.AddTagPair(color.Name()+"Accuracy", strings.TrimSuffix(accuracy, "%"))
+		}
+		table.Append([]string{color.Name(), GetTagPair(game, color.Name()), accuracy, avgLoss,
+			strconv.Itoa(side.inaccuracies), strconv.Itoa(side.mistakes), strconv.Itoa(side.blunders)})
+	}
+	table.Render()
+
+	// Save the annotated game.
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	// Annotator stays "pinata", loadPGN only loads games annotated so.
+	game.AddTagPair("AnalysisEngine", engine.Name)
+	if err := ioutil.WriteFile(filename, []byte(encodeAnnotatedPGN(game, notes)+"\n"), 0644); err != nil {
+		fmt.Println("Unable to save the analysis to", gConsole.Bold(gConsole.Red(filename)))
+		return err
+	}
+	fmt.Println("Analysis saved to", gConsole.Bold(gConsole.Red(filename)))
+	return nil
+}
+
+// Offer to analyze the finished game with the engine it was played against.
+func offerAnalysis(l *readline.Instance, engine *uci.Engine, game *chess.Game) {
+	l.SetPrompt("Analyze the game with " + engine.Name + "? [y/N] ")
+	answer, err := l.Readline()
+	if err != nil || !strings.HasPrefix(strings.ToLower(strings.TrimSpace(answer)), "y") {
+		return
+	}
+
+	if levelLimited() { // Analyze at full strength, then restore the level.
+		sendLevel(engine, 0, -1)
+		defer sendLevel(engine, gEngineElo, gEngineSkill)
+	}
+	if err := analyzeGame(engine, game, gameNotes(game), analysisFile(gLibraryFile)); err != nil {
+		fmt.Println("Unable to analyze the game,", err)
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWinPercent(t *testing.T) {
+	tests := []struct {
+		cp   int
+		want float64
+	}{
+		{0, 50},
+		{100, 59.10},
+		{-100, 40.90},
+		{300, 75.11},
+		{gMaxEvalCP, 97.54},
+	}
+	for _, test := range tests {
+		if got := winPercent(test.cp); !near(got, test.want, 0.01) {
+			t.Errorf("winPercent(%d) = %.2f, want %.2f", test.cp, got, test.want)
+		}
+	}
+}
+
+func TestMoveAccuracy(t *testing.T) {
+	tests := []struct {
+		before, after, want float64
+	}{
+		{50, 50, 100},
+		{50, 60, 100}, // Gaining chances is as good as keeping them.
+		{60, 50, 63.58},
+		{80, 20, 4.40},
+		{100, 0, 0},
+	}
+	for _, test := range tests {
+		if got := moveAccuracy(test.before, test.after); !near(got, test.want, 0.01) {
+			t.Errorf("moveAccuracy(%v, %v) = %.2f, want %.2f", test.before, test.after, got, test.want)
+		}
+	}
+}
+
+// SAN of the moves of a variation.
+func variationSAN(moves []*pgnMove) string {
+	var sans []string
+	for _, m := range moves {
+		sans = append(sans, m.san)
+	}
+	return strings.Join(sans, " ")
+}
+
+func TestBetterLine(t *testing.T) {
+	tests := []struct {
+		moves, line, want string
+	}{
+		{"", "e2e4 e7e5 d1h5 b8c6 f1c4 g8f6 h5f7", "e4 e5 Qh5 Nc6 Bc4 Nf6 Qxf7#"},
+		{"", "e2e4 e7e5 g1f3 b8c6 f1c4 g8f6 e1g1 f8c5 d2d3 d7d6", "e4 e5 Nf3 Nc6 Bc4 Nf6 O-O Bc5"}, // Cut to gBetterLinePly.
+		{"e2e4", "e7e5 g1f3", "e5 Nf3"},
+		{"", "e2e4 e2e4 d2d4", "e4"},
+		{"", "", ""},
+	}
+	for _, test := range tests {
+		if got := variationSAN(betterLine(playLAN(t, test.moves).Position(), strings.Fields(test.line))); got != test.want {
+			t.Errorf("betterLine(%q) after %q = %q, want %q", test.line, test.moves, got, test.want)
+		}
+	}
+}
+
+func TestEvalDecidedPosition(t *testing.T) {
+	tests := []struct {
+		fen  string
+		cp   int
+		eval string
+	}{
+		{"rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3", -gMaxEvalCP, "#-0"},
+		{"7k/5Q2/6K1/8/8/8/8/8 b - - 0 1", 0, "0.00"},
+	}
+	for _, test := range tests {
+		pos := gameFromFEN(t, test.fen).Position()
+		e, err := evalPosition(nil, pos) // Decided on the board, the engine is not asked.
+		if err != nil || e.cp != test.cp || e.eval != test.eval {
+			t.Errorf("evalPosition(%q) = %+v, %v, want %d %q", test.fen, e, err, test.cp, test.eval)
+		}
+	}
+}
+
+func TestHasVariation(t *testing.T) {
+	line := []*pgnMove{{san: "e4"}, {san: "e5"}}
+	tests := []struct {
+		name  string
+		after []pgnNote
+		want  bool
+	}{
+		{"none", nil, false},
+		{"same", []pgnNote{{comment: "old"}, {variation: []*pgnMove{{san: "e4"}, {san: "e5"}}}}, true},
+		{"other", []pgnNote{{variation: []*pgnMove{{san: "e4"}, {san: "c5"}}}}, false},
+		{"longer", []pgnNote{{variation: []*pgnMove{{san: "e4"}, {san: "e5"}, {san: "Nf3"}}}}, false},
+	}
+	for _, test := range tests {
+		if got := hasVariation(pgnMove{san: "d4", after: test.after}, line); got != test.want {
+			t.Errorf("%s: hasVariation = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSetMoveMark(t *testing.T) {
+	variation := []*pgnMove{{san: "e4"}}
+	tests := []struct {
+		name  string
+		after []pgnNote
+		nag   int
+		want  []pgnNote
+	}{
+		{"new", nil, 2, []pgnNote{{nag: 2, suffix: true}}},
+		{"none", nil, 0, nil},
+		{"replaced", []pgnNote{{nag: 1, suffix: true}}, 6, []pgnNote{{nag: 6, suffix: true}}},
+		{"removed", []pgnNote{{nag: 4}, {comment: "bad"}}, 0, []pgnNote{{comment: "bad"}}},
+		{"other NAGs kept", []pgnNote{{nag: 14}, {nag: 3}, {variation: variation}}, 4,
+			[]pgnNote{{nag: 4, suffix: true}, {nag: 14}, {variation: variation}}},
+	}
+	for _, test := range tests {
+		if got := setMoveMark(pgnMove{san: "d4", after: test.after}, test.nag).after; !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: setMoveMark = %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
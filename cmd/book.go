@@ -0,0 +1,202 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+	"github.com/abperiasamy/pinata/uci"
+)
+
+const gBookEntrySize = 16 // key, move, weight and learn fields of a Polyglot entry.
+
+// A Polyglot book entry. Entries are sorted by key.
+type bookEntry struct {
+	key    uint64
+	move   uint16
+	weight uint16
+}
+
+// Polyglot opening book.
+type openingBook struct {
+	entries []bookEntry
+}
+
+// Load a Polyglot opening book.
+func loadBook(filename string) (*openingBook, error) {
+	dat, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if len(dat)%gBookEntrySize != 0 {
+		return nil, fmt.Errorf("%s is not a Polyglot opening book", filename)
+	}
+
+	book := &openingBook{entries: make([]bookEntry, 0, len(dat)/gBookEntrySize)}
+	for i := 0; i < len(dat); i += gBookEntrySize {
+		book.entries = append(book.entries, bookEntry{
+			key:    binary.BigEndian.Uint64(dat[i:]),
+			move:   binary.BigEndian.Uint16(dat[i+8:]),
+			weight: binary.BigEndian.Uint16(dat[i+10:]),
+		})
+	}
+	return book, nil
+}
+
+// Polyglot piece kinds in key order, black before white.
+var gPolyglotKinds = map[chess.PieceType]int{
+	chess.Pawn: 0, chess.Knight: 1, chess.Bishop: 2, chess.Rook: 3, chess.Queen: 4, chess.King: 5,
+}
+
+// Polyglot Zobrist key of the position.
+func polyglotKey(pos *chess.Position) uint64 {
+	var key uint64
+	board := pos.Board()
+	for sq, piece := range board.SquareMap() {
+		kind := 2 * gPolyglotKinds[piece.Type()]
+		if piece.Color() == chess.White {
+			kind++
+		}
+		key ^= gPolyglotRandom[64*kind+8*int(sq.Rank())+int(sq.File())]
+	}
+
+	castling := pos.CastleRights()
+	for i, right := range []struct {
+		color chess.Color
+		side  chess.Side
+	}{{chess.White, chess.KingSide}, {chess.White, chess.QueenSide}, {chess.Black, chess.KingSide}, {chess.Black, chess.QueenSide}} {
+		if castling.CanCastle(right.color, right.side) {
+			key ^= gPolyglotRandom[768+i]
+		}
+	}
+
+	if file := enPassantFile(pos); file >= 0 {
+		key ^= gPolyglotRandom[772+file]
+	}
+
+	if pos.Turn() == chess.White {
+		key ^= gPolyglotRandom[780]
+	}
+	return key
+}
+
+// File of the en passant square, only if a pawn of the side to move
+// can capture there. Otherwise -1.
+func enPassantFile(pos *chess.Position) int {
+	ep := strings.Fields(pos.String())[3]
+	if ep == "-" {
+		return -1
+	}
+	file := int(ep[0] - 'a')
+	rank := chess.Rank5
+	pawn := chess.WhitePawn
+	if pos.Turn() == chess.Black {
+		rank, pawn = chess.Rank4, chess.BlackPawn
+	}
+	for _, f := range []int{file - 1, file + 1} {
+		if f >= 0 && f < 8 && pos.Board().Piece(chess.Square(8*int(rank)+f)) == pawn {
+			return file
+		}
+	}
+	return -1
+}
+
+// Decode a Polyglot move into the matching valid move of the position.
+func decodeBookMove(pos *chess.Position, move uint16) *chess.Move {
+	to := chess.Square(move & 0x3f)
+	from := chess.Square((move >> 6) & 0x3f)
+	promos := []chess.PieceType{chess.NoPieceType, chess.Knight, chess.Bishop, chess.Rook, chess.Queen}
+	if int(move>>12) >= len(promos) {
+		return nil
+	}
+	promo := promos[move>>12]
+
+	// Polyglot castles as the king capturing its own rook.
+	if pos.Board().Piece(from).Type() == chess.King {
+		switch {
+		case from == chess.E1 && to == chess.H1:
+			to = chess.G1
+		case from == chess.E1 && to == chess.A1:
+			to = chess.C1
+		case from == chess.E8 && to == chess.H8:
+			to = chess.G8
+		case from == chess.E8 && to == chess.A8:
+			to = chess.C8
+		}
+	}
+
+	for _, m := range pos.ValidMoves() {
+		if m.S1() == from && m.S2() == to && m.Promo() == promo {
+			return m
+		}
+	}
+	return nil
+}
+
+// Pick a book move for the position at random, weighted by the book.
+// Returns nil once the game is out of book.
+func (b *openingBook) pick(pos *chess.Position) *chess.Move {
+	key := polyglotKey(pos)
+	i := sort.Search(len(b.entries), func(i int) bool { return b.entries[i].key >= key })
+
+	var moves []*chess.Move
+	var weights []int
+	total := 0
+	for ; i < len(b.entries) && b.entries[i].key == key; i++ {
+		if move := decodeBookMove(pos, b.entries[i].move); move != nil && b.entries[i].weight > 0 {
+			moves = append(moves, move)
+			weights = append(weights, int(b.entries[i].weight))
+			total += int(b.entries[i].weight)
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	n := rand.Intn(total)
+	for i, w := range weights {
+		if n < w {
+			return moves[i]
+		}
+		n -= w
+	}
+	return nil // should never happen.
+}
+
+// Engine's reply from the opening book, nil once out of book.
+func bookResults(engine *uci.Engine, game *chess.Game) *uci.Results {
+	if gBook == nil {
+		return nil
+	}
+	move := gBook.pick(game.Position())
+	if move == nil {
+		return nil
+	}
+
+	// The book answers at once, the engine need not ponder any more.
+	gPonderMove = ""
+	if engine.Pondering() {
+		engine.StopPonder()
+	}
+	return &uci.Results{BestMove: move.String()}
+}
@@ -0,0 +1,171 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/abperiasamy/chess"
+)
+
+// Reference keys of the Polyglot book format specification.
+func TestPolyglotKey(t *testing.T) {
+	tests := []struct {
+		moves string
+		key   uint64
+	}{
+		{"", 0x463b96181691fc9c},
+		{"e2e4", 0x823c9b50fd114196},
+		{"e2e4 d7d5", 0x0756b94461c50fb0},
+		{"e2e4 d7d5 e4e5", 0x662fafb965db29d4},
+		{"e2e4 d7d5 e4e5 f7f5", 0x22a48b5a8e47ff78},
+		{"e2e4 d7d5 e4e5 f7f5 e1e2", 0x652a607ca3f242c1},
+		{"e2e4 d7d5 e4e5 f7f5 e1e2 e8f7", 0x00fdd303c946bdd9},
+		{"a2a4 b7b5 h2h4 b5b4 c2c4", 0x3c8123ea7b067637},
+		{"a2a4 b7b5 h2h4 b5b4 c2c4 b4c3 a1a3", 0x5c3f9b829b279560},
+	}
+	for _, test := range tests {
+		if key := polyglotKey(playLAN(t, test.moves).Position()); key != test.key {
+			t.Errorf("polyglotKey after %q = %016x, want %016x", test.moves, key, test.key)
+		}
+	}
+}
+
+// Polyglot move of the squares and promotion piece, 0 for none.
+func bookMove(from, to chess.Square, promo uint16) uint16 {
+	return promo<<12 | uint16(from)<<6 | uint16(to)
+}
+
+func TestDecodeBookMove(t *testing.T) {
+	castling := "e2e4 e7e5 g1f3 b8c6 f1c4 g8f6 d2d3 d7d6 c1e3 c8e6 b1c3 d8d7 d1d2 f8e7 h2h3"
+	tests := []struct {
+		moves string
+		move  uint16
+		want  string // Long algebraic, empty if not a valid move.
+	}{
+		{"", bookMove(chess.E2, chess.E4, 0), "e2e4"},
+		{"", bookMove(chess.G1, chess.F3, 0), "g1f3"},
+		{"", bookMove(chess.E2, chess.E5, 0), ""},
+		{castling + " e8c8", bookMove(chess.E1, chess.H1, 0), "e1g1"},
+		{castling + " e8c8", bookMove(chess.E1, chess.A1, 0), "e1c1"},
+		{castling, bookMove(chess.E8, chess.H8, 0), "e8g8"},
+		{castling, bookMove(chess.E8, chess.A8, 0), "e8c8"},
+		{"a2a4 b7b5 a4b5 g8f6 b5b6 f6e4 b6c7 e4d2", bookMove(chess.C7, chess.B8, 4), "c7b8q"},
+		{"a2a4 b7b5 a4b5 g8f6 b5b6 f6e4 b6c7 e4d2", bookMove(chess.C7, chess.D8, 1), "c7d8n"},
+		{"", 7 << 12, ""},
+	}
+	for _, test := range tests {
+		pos := playLAN(t, test.moves).Position()
+		got := ""
+		if m := decodeBookMove(pos, test.move); m != nil {
+			got = chess.Encoder.Encode(chess.LongAlgebraicNotation{}, pos, m)
+		}
+		if got != test.want {
+			t.Errorf("decodeBookMove(%04x) after %q = %q, want %q", test.move, test.moves, got, test.want)
+		}
+	}
+}
+
+// Write the entries as a Polyglot book file.
+func writeBook(t *testing.T, entries []bookEntry) string {
+	t.Helper()
+	data := make([]byte, 0, gBookEntrySize*len(entries))
+	for _, e := range entries {
+		entry := make([]byte, gBookEntrySize)
+		binary.BigEndian.PutUint64(entry, e.key)
+		binary.BigEndian.PutUint16(entry[8:], e.move)
+		binary.BigEndian.PutUint16(entry[10:], e.weight)
+		data = append(data, entry...)
+	}
+	filename := filepath.Join(t.TempDir(), "book.bin")
+	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestOpeningBook(t *testing.T) {
+	start := polyglotKey(chess.NewGame().Position())
+	entries := []bookEntry{
+		{key: start - 1, move: bookMove(chess.D2, chess.D4, 0), weight: 10},
+		{key: start, move: bookMove(chess.E2, chess.E4, 0), weight: 1},
+		{key: start, move: bookMove(chess.E2, chess.E5, 0), weight: 10}, // Not a valid move.
+		{key: start, move: bookMove(chess.C2, chess.C4, 0), weight: 0},  // Never played.
+		{key: start + 1, move: bookMove(chess.G1, chess.F3, 0), weight: 10},
+	}
+	filename := writeBook(t, entries)
+	book, err := loadBook(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pos := chess.NewGame().Position()
+	for i := 0; i < 20; i++ {
+		m := book.pick(pos)
+		if m == nil || chess.Encoder.Encode(chess.LongAlgebraicNotation{}, pos, m) != "e2e4" {
+			t.Fatalf("pick = %v, want e2e4, the only playable book move", m)
+		}
+	}
+	if m := book.pick(playLAN(t, "e2e4").Position()); m != nil {
+		t.Errorf("pick out of book = %v, want nil", m)
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filename, data[:len(data)-1], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadBook(filename); err == nil {
+		t.Error("loadBook accepted a truncated book")
+	}
+}
+
+func TestBookMoveClock(t *testing.T) {
+	engine := startFakeEngine(t)
+	start := polyglotKey(chess.NewGame().Position())
+	book, err := loadBook(writeBook(t, []bookEntry{{key: start, move: bookMove(chess.D2, chess.D4, 0), weight: 1}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gBook, gHumanIsBlack = book, true
+	gClock, _ = newChessClock("5+3")
+	gClockHistory = nil
+	t.Cleanup(func() { gBook, gHumanIsBlack, gClock, gClockHistory = nil, false, nil, nil })
+
+	// The book move earns the increment, recorded for its %clk comment.
+	game := playLAN(t, "")
+	captureOutput(t, func() {
+		if err := engineMoveFirst(engine, game); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if got := gameLAN(game); got != "d2d4" {
+		t.Fatalf("engine played %q, want the book move d2d4", got)
+	}
+	want := 5*time.Minute + 3*time.Second
+	if got := gClock.remaining[chess.White]; got.Round(time.Second) != want {
+		t.Errorf("white clock after the book move = %v, want %v", got, want)
+	}
+	if len(gClockHistory) != 1 || gClockHistory[0].Round(time.Second) != want {
+		t.Errorf("clock history = %v, want [%v]", gClockHistory, want)
+	}
+}
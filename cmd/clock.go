@@ -0,0 +1,226 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abperiasamy/chess"
+	"github.com/abperiasamy/pinata/uci"
+)
+
+// Chess clock for both sides. Time control is "base+increment" or "base d delay",
+// base in minutes and increment or delay in seconds.
+type chessClock struct {
+	base      time.Duration
+	increment time.Duration
+	delay     time.Duration // simple (US) delay before the clock starts running
+
+	remaining map[chess.Color]time.Duration
+	running   chess.Color // NoColor while the clock is stopped
+	started   time.Time
+}
+
+var timeControlRegex = regexp.MustCompile(`^(\d+(?:\.\d+)?)(?:([+d])(\d+))?$`)
+
+// Parse a time control like "5+3", "5d2" or "15".
+func newChessClock(control string) (*chessClock, error) {
+	m := timeControlRegex.FindStringSubmatch(strings.TrimSpace(control))
+	if m == nil {
+		return nil, fmt.Errorf("invalid time control %q, use minutes+seconds like 5+3 or 5d2", control)
+	}
+
+	minutes, _ := strconv.ParseFloat(m[1], 64)
+	if minutes <= 0 {
+		return nil, fmt.Errorf("invalid time control %q, base time must be positive", control)
+	}
+	clock := &chessClock{base: time.Duration(minutes * float64(time.Minute))}
+	if m[3] != "" {
+		seconds, _ := strconv.Atoi(m[3])
+		if m[2] == "+" {
+			clock.increment = time.Duration(seconds) * time.Second
+		} else {
+			clock.delay = time.Duration(seconds) * time.Second
+		}
+	}
+	clock.remaining = map[chess.Color]time.Duration{chess.White: clock.base, chess.Black: clock.base}
+	return clock, nil
+}
+
+// Parse the PGN TimeControl tag written by savePGN.
+func clockFromTag(tag, delay string) (*chessClock, error) {
+	parts := strings.SplitN(tag, "+", 2)
+	seconds, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("unsupported time control %q", tag)
+	}
+	control := strconv.FormatFloat(float64(seconds)/60, 'f', -1, 64)
+	if len(parts) == 2 {
+		control += "+" + parts[1]
+	} else if delay != "" {
+		control += "d" + delay
+	}
+	return newChessClock(control)
+}
+
+// PGN TimeControl tag value, base and increment in seconds.
+func (c *chessClock) timeControl() string {
+	tc := strconv.Itoa(int(c.base.Seconds()))
+	if c.increment > 0 {
+		tc += "+" + strconv.Itoa(int(c.increment.Seconds()))
+	}
+	return tc
+}
+
+// Start the clock for the side to move.
+func (c *chessClock) start(color chess.Color) {
+	c.running = color
+	c.started = time.Now()
+}
+
+// Time left for the given side, counting the move in progress.
+func (c *chessClock) timeLeft(color chess.Color) time.Duration {
+	left := c.remaining[color]
+	if c.running == color {
+		if spent := time.Since(c.started) - c.delay; spent > 0 {
+			left -= spent
+		}
+	}
+	return left
+}
+
+// Stop the running clock after a move and add the increment.
+// Returns false if the side to move ran out of time.
+func (c *chessClock) stop() bool {
+	color := c.running
+	if color == chess.NoColor {
+		return true
+	}
+	c.remaining[color] = c.timeLeft(color)
+	c.running = chess.NoColor
+	if c.remaining[color] <= 0 {
+		c.remaining[color] = 0
+		return false // Flag fell.
+	}
+	c.remaining[color] += c.increment
+	return true
+}
+
+// Search limits handing both clocks to the engine.
+func (c *chessClock) limits() uci.Limits {
+	return uci.Limits{
+		WhiteTime: c.timeLeft(chess.White),
+		BlackTime: c.timeLeft(chess.Black),
+		WhiteInc:  c.increment,
+		BlackInc:  c.increment,
+	}
+}
+
+// Format the remaining time as m:ss, or h:mm:ss for long games.
+func formatClock(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	d = d.Truncate(time.Second)
+	h, m, s := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
+
+// PGN %clk command value, always h:mm:ss.
+func formatClk(d time.Duration) string {
+	d = d.Truncate(time.Second)
+	return fmt.Sprintf("%d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+}
+
+var clkRegex = regexp.MustCompile(`\[%clk\s+(\d+):(\d+):(\d+)(?:\.\d+)?\]`)
+
+// Collect the %clk comments of a PGN file in move order.
+func parseClocks(pgn string) (clocks []time.Duration) {
+	for _, m := range clkRegex.FindAllStringSubmatch(pgn, -1) {
+		h, _ := strconv.Atoi(m[1])
+		min, _ := strconv.Atoi(m[2])
+		s, _ := strconv.Atoi(m[3])
+		clocks = append(clocks, time.Duration(h)*time.Hour+time.Duration(min)*time.Minute+time.Duration(s)*time.Second)
+	}
+	return clocks
+}
+
+// Record the remaining time of the side that just moved.
+func recordClock(game *chess.Game, color chess.Color) {
+	ply := len(game.Moves())
+	for len(gClockHistory) < ply-1 { // Moves replayed by /redo have no clock reading.
+		gClockHistory = append(gClockHistory, -1)
+	}
+	gClockHistory = append(gClockHistory[:ply-1], gClock.remaining[color])
+}
+
+// Set both clocks back to their last readings in the clock history, after
+// moves were taken back. A side without a reading gets the base time.
+func restoreClock(game *chess.Game) {
+	if gClock == nil {
+		return
+	}
+	positions := game.Positions()
+	gClock.remaining[chess.White], gClock.remaining[chess.Black] = gClock.base, gClock.base
+	for i, reading := range gClockHistory {
+		if i < len(positions) && reading >= 0 {
+			gClock.remaining[positions[i].Turn()] = reading
+		}
+	}
+	if gClock.running != chess.NoColor {
+		gClock.start(game.Position().Turn())
+	}
+}
+
+// End the game in favour of the opponent when a flag falls, drawn if the
+// opponent has no mating material.
+func flagFall(game *chess.Game, color chess.Color) {
+	gTermination = "time forfeit"
+	fmt.Println(gConsole.Bold(gConsole.Red(color.Name())), "ran out of time.")
+	if !canMate(game.Position().Board(), color.Other()) {
+		fmt.Println(gConsole.Bold(gConsole.Yellow(color.Other().Name())), "cannot mate, the game is drawn.")
+		game.Draw(chess.DrawOffer) // The Termination tag tells it was not agreed.
+		return
+	}
+	game.Resign(color)
+}
+
+// Whether the side could mate at all. A lone king can't, nor a king and a
+// minor piece against a lone king.
+func canMate(board *chess.Board, color chess.Color) bool {
+	pieces := map[chess.Color][]chess.PieceType{}
+	for _, p := range board.SquareMap() {
+		if p.Type() != chess.King {
+			pieces[p.Color()] = append(pieces[p.Color()], p.Type())
+		}
+	}
+	own, theirs := pieces[color], pieces[color.Other()]
+	switch {
+	case len(own) == 0:
+		return false
+	case len(own) == 1 && len(theirs) == 0:
+		return own[0] != chess.Bishop && own[0] != chess.Knight
+	}
+	return true
+}
@@ -0,0 +1,211 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/abperiasamy/chess"
+	"github.com/abperiasamy/pinata/uci"
+)
+
+func TestNewChessClock(t *testing.T) {
+	tests := []struct {
+		control                string
+		base, increment, delay time.Duration
+		tag                    string
+	}{
+		{"5+3", 5 * time.Minute, 3 * time.Second, 0, "300+3"},
+		{"15", 15 * time.Minute, 0, 0, "900"},
+		{" 90+30 ", 90 * time.Minute, 30 * time.Second, 0, "5400+30"},
+		{"0.5+1", 30 * time.Second, time.Second, 0, "30+1"},
+		{"5d2", 5 * time.Minute, 0, 2 * time.Second, "300"},
+	}
+	for _, test := range tests {
+		clock, err := newChessClock(test.control)
+		if err != nil {
+			t.Errorf("newChessClock(%q) failed: %v", test.control, err)
+			continue
+		}
+		if clock.base != test.base || clock.increment != test.increment || clock.delay != test.delay {
+			t.Errorf("newChessClock(%q) = %v+%v delay %v, want %v+%v delay %v", test.control,
+				clock.base, clock.increment, clock.delay, test.base, test.increment, test.delay)
+		}
+		if clock.remaining[chess.White] != test.base || clock.remaining[chess.Black] != test.base {
+			t.Errorf("newChessClock(%q) starts with %v, want %v for both sides", test.control, clock.remaining, test.base)
+		}
+		if tag := clock.timeControl(); tag != test.tag {
+			t.Errorf("newChessClock(%q).timeControl() = %q, want %q", test.control, tag, test.tag)
+		}
+	}
+
+	for _, control := range []string{"", "0", "0+5", "5+", "+3", "5:00", "5+3+1", "-5", "5x3"} {
+		if _, err := newChessClock(control); err == nil {
+			t.Errorf("newChessClock(%q) succeeded, want an error", control)
+		}
+	}
+}
+
+func TestClockFromTag(t *testing.T) {
+	tests := []struct {
+		tag, tagDelay          string
+		base, increment, delay time.Duration
+	}{
+		{"300+3", "", 5 * time.Minute, 3 * time.Second, 0},
+		{"900", "", 15 * time.Minute, 0, 0},
+		{"30+1", "", 30 * time.Second, time.Second, 0},
+		{"300", "2", 5 * time.Minute, 0, 2 * time.Second},
+	}
+	for _, test := range tests {
+		clock, err := clockFromTag(test.tag, test.tagDelay)
+		if err != nil {
+			t.Errorf("clockFromTag(%q, %q) failed: %v", test.tag, test.tagDelay, err)
+			continue
+		}
+		if clock.base != test.base || clock.increment != test.increment || clock.delay != test.delay {
+			t.Errorf("clockFromTag(%q, %q) = %v+%v delay %v, want %v+%v delay %v", test.tag, test.tagDelay,
+				clock.base, clock.increment, clock.delay, test.base, test.increment, test.delay)
+		}
+	}
+
+	for _, tag := range []string{"40/9000", "?", "-"} {
+		if _, err := clockFromTag(tag, ""); err == nil {
+			t.Errorf("clockFromTag(%q) succeeded, want an error", tag)
+		}
+	}
+}
+
+func TestClockStop(t *testing.T) {
+	clock, _ := newChessClock("1+2")
+	clock.start(chess.White)
+	clock.started = time.Now().Add(-10 * time.Second)
+	if !clock.stop() {
+		t.Fatal("the flag fell after 10 seconds of a minute")
+	}
+	if left := clock.remaining[chess.White]; left < 51*time.Second || left > 52*time.Second {
+		t.Errorf("white has %v left, want about 52s after 10s and a 2s increment", left)
+	}
+	if !clock.stop() || clock.remaining[chess.White] > 52*time.Second {
+		t.Error("stopping a stopped clock changed it")
+	}
+
+	clock.start(chess.Black)
+	clock.started = time.Now().Add(-2 * time.Minute)
+	if clock.stop() {
+		t.Error("the flag did not fall after 2 minutes of a minute")
+	}
+	if left := clock.remaining[chess.Black]; left != 0 {
+		t.Errorf("black has %v left after the flag fell, want 0", left)
+	}
+
+	delayed, _ := newChessClock("1d5")
+	delayed.start(chess.White)
+	delayed.started = time.Now().Add(-3 * time.Second)
+	delayed.stop()
+	if left := delayed.remaining[chess.White]; left != time.Minute {
+		t.Errorf("white has %v left after 3s within a 5s delay, want 1m0s", left)
+	}
+}
+
+func TestFormatClock(t *testing.T) {
+	tests := []struct {
+		d          time.Duration
+		clock, clk string
+	}{
+		{0, "0:00", "0:00:00"},
+		{59*time.Second + 900*time.Millisecond, "0:59", "0:00:59"},
+		{5*time.Minute + 3*time.Second, "5:03", "0:05:03"},
+		{time.Hour + 2*time.Minute + 3*time.Second, "1:02:03", "1:02:03"},
+	}
+	for _, test := range tests {
+		if got := formatClock(test.d); got != test.clock {
+			t.Errorf("formatClock(%v) = %q, want %q", test.d, got, test.clock)
+		}
+		if got := formatClk(test.d); got != test.clk {
+			t.Errorf("formatClk(%v) = %q, want %q", test.d, got, test.clk)
+		}
+	}
+	if got := formatClock(-time.Second); got != "0:00" {
+		t.Errorf("formatClock(-1s) = %q, want 0:00", got)
+	}
+}
+
+func TestParseClocks(t *testing.T) {
+	pgn := "1. e4 {[%clk 0:04:58]} 1... e5 {good [%clk 0:04:57.5] [%eval 0.3]} 2. Nf3 {[%clk 1:00:00]} 2... Nc6 *"
+	want := []time.Duration{4*time.Minute + 58*time.Second, 4*time.Minute + 57*time.Second, time.Hour}
+	if got := parseClocks(pgn); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseClocks = %v, want %v", got, want)
+	}
+	if got := parseClocks("1. e4 e5 *"); got != nil {
+		t.Errorf("parseClocks without clocks = %v, want none", got)
+	}
+}
+
+func TestClockLimits(t *testing.T) {
+	clock, _ := newChessClock("5+3")
+	clock.remaining[chess.Black] = 4 * time.Minute
+	want := uci.Limits{WhiteTime: 5 * time.Minute, BlackTime: 4 * time.Minute, WhiteInc: 3 * time.Second, BlackInc: 3 * time.Second}
+	if got := clock.limits(); got != want {
+		t.Errorf("limits = %+v, want %+v", got, want)
+	}
+}
+
+func TestRecordClock(t *testing.T) {
+	gClock, _ = newChessClock("5")
+	gClockHistory = nil
+	t.Cleanup(func() { gClock, gClockHistory = nil, nil })
+
+	game := playLAN(t, "e2e4 e7e5")
+	gClock.remaining[chess.White] = 4 * time.Minute
+	recordClock(playLAN(t, "e2e4"), chess.White)
+	recordClock(game, chess.Black)
+	if want := []time.Duration{4 * time.Minute, 5 * time.Minute}; !reflect.DeepEqual(gClockHistory, want) {
+		t.Errorf("clock history = %v, want %v", gClockHistory, want)
+	}
+
+	// Moves replayed without a clock reading are unknown.
+	recordClock(playLAN(t, "e2e4 e7e5 g1f3 b8c6"), chess.Black)
+	if want := []time.Duration{4 * time.Minute, 5 * time.Minute, -1, 5 * time.Minute}; !reflect.DeepEqual(gClockHistory, want) {
+		t.Errorf("clock history = %v, want %v", gClockHistory, want)
+	}
+}
+
+func TestFlagFall(t *testing.T) {
+	t.Cleanup(func() { gTermination = "" })
+	tests := []struct {
+		name, fen string
+		flagged   chess.Color
+		want      chess.Outcome
+	}{
+		{"opponent has a pawn", "4k3/8/8/8/8/8/4P3/4K3 b - - 0 1", chess.Black, chess.WhiteWon},
+		{"opponent has a lone king", "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1", chess.White, chess.Draw},
+		{"knight against a pawn", "4k3/4p3/8/8/8/8/8/2N1K3 b - - 0 1", chess.Black, chess.WhiteWon},
+		{"bishop against a rook", "4k3/4r3/8/8/8/8/8/2B1K3 b - - 0 1", chess.Black, chess.WhiteWon},
+		{"lone king against a rook", "4k3/4r3/8/8/8/8/8/4K3 b - - 0 1", chess.Black, chess.Draw},
+		{"rook against a lone king", "4k3/8/8/8/8/8/8/R3K3 b - - 0 1", chess.Black, chess.WhiteWon},
+	}
+	for _, test := range tests {
+		gTermination = ""
+		game := gameFromFEN(t, test.fen)
+		flagFall(game, test.flagged)
+		if game.Outcome() != test.want || gTermination != "time forfeit" {
+			t.Errorf("%s: %s with termination %q, want %s by time forfeit", test.name, game.Outcome(), gTermination, test.want)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// configCmd shows and changes the settings of the config file
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show or change the default settings kept in the config file.",
+	Long: `The config file sets the default value of any flag, like
+  pinata config set engine /usr/local/bin/stockfish
+  pinata config set depth 15
+Flags given on the command line take precedence over the config file.`,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the settings and where they come from.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		initGlobals()
+		showConfig()
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <flag> <value>",
+	Short: "Save the default value of a flag in the config file.",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := setConfig(args[0], args[1]); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <flag>",
+	Short: "Remove a flag from the config file, restoring its built-in default.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := setConfig(args[0], ""); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configShowCmd, configSetCmd, configUnsetCmd)
+}
+
+// Default config file in the user's config directory.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "pinata.json"
+	}
+	return filepath.Join(dir, "pinata", "config.json")
+}
+
+// Read the config file as flag names and values. A missing file is an empty config.
+func readConfig(filename string) (map[string]interface{}, error) {
+	config := make(map[string]interface{})
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return config, nil
+	} else if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // Keep 2000000 from reading back as 2e+06.
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", filename, err)
+	}
+	return config, nil
+}
+
+// Write the config file, creating its directory if needed.
+func writeConfig(filename string, config map[string]interface{}) error {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, append(data, '\n'), 0600)
+}
+
+// Flag of the config file, nil for an unknown flag, the --config flag itself or
+// --analyze, whose access-token belongs in the --analyze-token-file.
+func configFlag(name string) *pflag.Flag {
+	if name == "config" || name == "help" || name == "version" || name == "analyze" {
+		return nil
+	}
+	return rootCmd.PersistentFlags().Lookup(name)
+}
+
+// Config value as typed JSON, so "depth": 15 and "visual": true read naturally.
+func configValue(flag *pflag.Flag, value string) (interface{}, error) {
+	switch flag.Value.Type() {
+	case "bool":
+		return strconv.ParseBool(value)
+	case "int":
+		return strconv.Atoi(value)
+	}
+	return value, nil
+}
+
+// Apply the config file to the flags not given on the command line.
+func initConfig() {
+	if gCfgFile == "" { // A missing config file is an empty config.
+		gCfgFile = defaultConfigPath()
+	}
+
+	// Bad entries are skipped with a warning, so that config unset can still remove them.
+	config, err := readConfig(gCfgFile)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for name, value := range config {
+		flag := configFlag(name)
+		if flag == nil {
+			fmt.Println("Ignoring unknown flag", name, "in", gCfgFile)
+			continue
+		}
+		if flag.Changed { // The command line takes precedence.
+			continue
+		}
+		previous := flag.Value.String()
+		if err := flag.Value.Set(fmt.Sprint(value)); err != nil {
+			flag.Value.Set(previous) // Numeric flags are zeroed by a failed Set.
+			fmt.Println("Ignoring invalid", name, "in", gCfgFile+":", err)
+		}
+	}
+}
+
+// Save the default value of a flag, removing it for an empty value.
+func setConfig(name, value string) error {
+	name = strings.TrimPrefix(name, "--")
+	config, err := readConfig(gCfgFile)
+	if err != nil {
+		return err
+	}
+	flag := configFlag(name)
+	if _, ok := config[name]; ok && value == "" { // Unknown flags can be removed too.
+		delete(config, name)
+	} else if name == "analyze" {
+		return fmt.Errorf("keep the access-token in a file with analyze-token-file instead")
+	} else if flag == nil {
+		return fmt.Errorf("unknown flag %q, see pinata --help", name)
+	} else if value == "" {
+		delete(config, flag.Name)
+	} else {
+		if err := flag.Value.Set(value); err != nil { // Reject values the flag would.
+			return fmt.Errorf("invalid value %q for %s: %v", value, flag.Name, err)
+		}
+		if config[flag.Name], err = configValue(flag, value); err != nil {
+			return err
+		}
+	}
+	return writeConfig(gCfgFile, config)
+}
+
+// Print every flag with its value and whether the config file sets it.
+func showConfig() {
+	config, err := readConfig(gCfgFile)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Println("Config file:", gConsole.Bold(gConsole.Yellow(gCfgFile)))
+
+	var flags []*pflag.Flag
+	rootCmd.PersistentFlags().VisitAll(func(flag *pflag.Flag) {
+		if configFlag(flag.Name) != nil {
+			flags = append(flags, flag)
+		}
+	})
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Flag", "Value", "Source"})
+	for _, flag := range flags {
+		value := flag.Value.String()
+		if flag.Name == "analyze" && value != "" { // Keep the access-token private.
+			value = "********"
+		}
+		source := "default"
+		if flag.Changed {
+			source = "command line"
+		} else if _, ok := config[flag.Name]; ok {
+			source = "config"
+		}
+		table.Append([]string{flag.Name, value, source})
+	}
+	table.Render()
+}
@@ -0,0 +1,138 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// Use a config file in a temporary directory, restoring the flags afterwards.
+func useConfig(t *testing.T) string {
+	saved := gCfgFile
+	gCfgFile = filepath.Join(t.TempDir(), "pinata", "config.json")
+	t.Cleanup(func() {
+		rootCmd.PersistentFlags().VisitAll(func(flag *pflag.Flag) {
+			flag.Value.Set(flag.DefValue)
+			flag.Changed = false
+		})
+		gCfgFile = saved
+	})
+	return gCfgFile
+}
+
+func TestReadWriteConfig(t *testing.T) {
+	filename := useConfig(t)
+	if config, err := readConfig(filename); err != nil || len(config) != 0 {
+		t.Fatalf("readConfig of a missing file = %v, %v, want an empty config", config, err)
+	}
+
+	config := map[string]interface{}{"engine": "/usr/local/bin/stockfish", "nodes": json.Number("2000000"), "visual": true}
+	if err := writeConfig(filename, config); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, config) {
+		t.Errorf("readConfig = %v, want %v", got, config)
+	}
+
+	if err := ioutil.WriteFile(filename, []byte("{depth: 15"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readConfig(filename); err == nil {
+		t.Error("readConfig accepted invalid JSON")
+	}
+}
+
+func TestSetConfig(t *testing.T) {
+	filename := useConfig(t)
+	for _, set := range [][2]string{{"depth", "15"}, {"--visual", "true"}, {"keys", "vi"}, {"elo", "1500"}, {"elo", ""}} {
+		if err := setConfig(set[0], set[1]); err != nil {
+			t.Fatalf("setConfig(%q, %q) failed: %v", set[0], set[1], err)
+		}
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"depth\": 15,\n  \"keys\": \"vi\",\n  \"visual\": true\n}\n"
+	if string(data) != want {
+		t.Errorf("config file = %q, want %q", data, want)
+	}
+
+	config := map[string]interface{}{"depth": 15, "retired-flag": true}
+	if err := writeConfig(filename, config); err != nil {
+		t.Fatal(err)
+	}
+	if err := setConfig("retired-flag", ""); err != nil {
+		t.Errorf("unsetting an unknown entry failed: %v", err)
+	}
+	if got, _ := readConfig(filename); len(got) != 1 {
+		t.Errorf("config after unset = %v, want only depth", got)
+	}
+
+	for _, set := range [][2]string{{"config", "other.json"}, {"help", "true"}, {"analyze", "token"}, {"no-such-flag", "1"}, {"depth", "deep"}, {"visual", "maybe"}} {
+		if err := setConfig(set[0], set[1]); err == nil {
+			t.Errorf("setConfig(%q, %q) succeeded, want an error", set[0], set[1])
+		}
+	}
+}
+
+func TestInitConfig(t *testing.T) {
+	filename := useConfig(t)
+	config := map[string]interface{}{"engine": "/opt/engine", "depth": 15, "visual": true,
+		"nodes": 2000000, "skill": "strong", "retired-flag": true, "analyze": "token"} // Bad entries are skipped.
+	if err := writeConfig(filename, config); err != nil {
+		t.Fatal(err)
+	}
+	if err := rootCmd.PersistentFlags().Set("depth", "7"); err != nil { // Given on the command line.
+		t.Fatal(err)
+	}
+
+	initConfig()
+	if gEngineBinary != "/opt/engine" || !gVisual {
+		t.Errorf("engine %q and visual %v, want the config's /opt/engine and true", gEngineBinary, gVisual)
+	}
+	if gEngineDepth != 7 {
+		t.Errorf("depth = %d, want 7 from the command line over the config", gEngineDepth)
+	}
+	if gEngineNodes != 2000000 {
+		t.Errorf("nodes = %d, want 2000000", gEngineNodes)
+	}
+	if gEngineSkill != -1 {
+		t.Errorf("skill = %d, want the default -1 for an invalid entry", gEngineSkill)
+	}
+	if gLichessAuthTok != "" {
+		t.Errorf("access-token %q read from the config", gLichessAuthTok)
+	}
+
+	// A config file named on the command line but not written yet is empty.
+	gCfgFile = filepath.Join(t.TempDir(), "new.json")
+	gEngineBinary = "stockfish"
+	initConfig()
+	if gEngineBinary != "stockfish" {
+		t.Errorf("engine %q from a missing config file", gEngineBinary)
+	}
+}
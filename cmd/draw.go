@@ -0,0 +1,132 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+	"github.com/abperiasamy/pinata/uci"
+	"github.com/chzyer/readline"
+)
+
+const (
+	gDrawEndgameMaterial = 2600 // Material on board (centipawns, kings excluded) below which the game is an endgame.
+	gDrawEndgameMargin   = 25   // Engine accepts a draw in the endgame unless it is better by more than this.
+	gDrawMargin          = -50  // Engine accepts a draw in the middlegame only when it is worse by more than this.
+)
+
+// Material values in centipawns used to judge a draw offer.
+var gPieceValues = map[chess.PieceType]int{
+	chess.Queen:  900,
+	chess.Rook:   500,
+	chess.Bishop: 330,
+	chess.Knight: 320,
+	chess.Pawn:   100,
+}
+
+// Total material left on the board, kings excluded.
+func materialLeft(board *chess.Board) (material int) {
+	for _, piece := range board.SquareMap() {
+		material += gPieceValues[piece.Type()]
+	}
+	return material
+}
+
+// Claim a threefold repetition or fifty-move draw, or else offer a draw to the
+// opponent. Returns true if the game ended in a draw.
+func drawGame(engine *uci.Engine, l *readline.Instance, game *chess.Game) bool {
+	for _, method := range game.EligibleDraws() {
+		if method == chess.ThreefoldRepetition || method == chess.FiftyMoveRule {
+			if game.Draw(method) == nil {
+				return true
+			}
+		}
+	}
+
+	opponent := playerName(game.Position().Turn().Other())
+	var accepted bool
+	if gHumanVsHuman {
+		accepted = humanAcceptsDraw(l, opponent)
+	} else {
+		accepted = engineAcceptsDraw(engine, game)
+	}
+
+	if accepted {
+		fmt.Println(gConsole.Bold(gConsole.Yellow(opponent)), "accepts the draw offer.")
+		return game.Draw(chess.DrawOffer) == nil
+	}
+	fmt.Println(gConsole.Bold(gConsole.Yellow(opponent)), "declines the draw offer.")
+	return false
+}
+
+// Ask the other player at the prompt.
+func humanAcceptsDraw(l *readline.Instance, opponent string) bool {
+	l.SetPrompt(opponent + ", do you accept the draw? [yes|no] ")
+	answer, err := l.Readline()
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// The engine accepts a draw when it is worse, or when the endgame is about level.
+func engineAcceptsDraw(engine *uci.Engine, game *chess.Game) bool {
+	engine.SetFEN(game.FEN())
+	results, err := engine.Go(gSearchLimits, uci.HighestDepthOnly)
+	if err != nil {
+		fmt.Println(err)
+		return false
+	}
+	top := topMoves(results)
+	if len(top) == 0 {
+		return false // No evaluation, play on.
+	}
+
+	// Engine reports the score of the human, who is to move.
+	score := top[0]
+	if score.Mate {
+		return score.Score > 0 // Only when getting mated.
+	}
+	engineScore := -score.Score
+	if engineScore < gDrawMargin {
+		return true
+	}
+	return materialLeft(game.Position().Board()) <= gDrawEndgameMaterial && engineScore <= gDrawEndgameMargin
+}
+
+// Short name of the engine for messages.
+func engineName() string {
+	return filepath.Base(gEngineBinary)
+}
+
+// Name of the player of the given color for messages.
+func playerName(color chess.Color) string {
+	if gHumanVsHuman {
+		if color == chess.White {
+			return gWhiteName
+		}
+		return gBlackName
+	}
+	if color == humanColor() {
+		return "Human"
+	}
+	return engineName()
+}
@@ -0,0 +1,84 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"testing"
+
+	"github.com/abperiasamy/chess"
+)
+
+func TestMaterialLeft(t *testing.T) {
+	tests := []struct {
+		fen  string
+		want int
+	}{
+		{testStartFEN, 8000},
+		{"4k3/8/8/8/8/8/8/4K3 w - - 0 1", 0},
+		{"4k3/4p3/8/8/8/8/8/R3K3 w - - 0 1", 600},
+	}
+	for _, test := range tests {
+		fen, _ := chess.FEN(test.fen)
+		if got := materialLeft(chess.NewGame(fen).Position().Board()); got != test.want {
+			t.Errorf("materialLeft(%q) = %d, want %d", test.fen, got, test.want)
+		}
+	}
+}
+
+func TestDrawGame(t *testing.T) {
+	engine := startFakeEngine(t) // Always evaluates the position as level.
+	tests := []struct {
+		name   string
+		game   *chess.Game
+		method chess.Method // NoMethod if the game goes on.
+	}{
+		{"declined in the middlegame", playLAN(t, "e2e4 e7e5"), chess.NoMethod},
+		{"accepted in a level endgame", gameFromFEN(t, "4k3/4p3/8/8/8/8/4P3/R3K3 w - - 0 1"), chess.DrawOffer},
+		{"repetition claimed", playLAN(t, "g1f3 g8f6 f3g1 f6g8 g1f3 g8f6 f3g1 f6g8"), chess.ThreefoldRepetition},
+	}
+	for _, test := range tests {
+		drawn := drawGame(engine, nil, test.game)
+		if drawn != (test.method != chess.NoMethod) || test.game.Method() != test.method {
+			t.Errorf("%s: drawGame = %v with %s, want %s", test.name, drawn, test.game.Method(), test.method)
+		}
+	}
+}
+
+func TestPlayerName(t *testing.T) {
+	saved := []string{gEngineBinary, gWhiteName, gBlackName}
+	t.Cleanup(func() {
+		gEngineBinary, gWhiteName, gBlackName = saved[0], saved[1], saved[2]
+		gHumanVsHuman, gHumanIsBlack = false, false
+	})
+	gEngineBinary, gWhiteName, gBlackName = "/usr/games/stockfish", "Anand", "Magnus"
+
+	tests := []struct {
+		duel, humanIsBlack bool
+		white, black       string
+	}{
+		{false, false, "Human", "stockfish"},
+		{false, true, "stockfish", "Human"},
+		{true, false, "Anand", "Magnus"},
+	}
+	for _, test := range tests {
+		gHumanVsHuman, gHumanIsBlack = test.duel, test.humanIsBlack
+		if white, black := playerName(chess.White), playerName(chess.Black); white != test.white || black != test.black {
+			t.Errorf("players of duel %v, human black %v = %s, %s, want %s, %s",
+				test.duel, test.humanIsBlack, white, black, test.white, test.black)
+		}
+	}
+}
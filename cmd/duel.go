@@ -0,0 +1,40 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// duelCmd plays a human vs human pass-and-play game
+var duelCmd = &cobra.Command{
+	Use:   "duel",
+	Short: "Play blindfold chess against another human at the same prompt.",
+	Long: `Both players enter their moves at the same prompt, which shows the side to move.
+Use --white-name and --black-name to record the players in the saved game.`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Flags().Set("human-vs-human", "true") // Given on the command line, so a resumed journal keeps it.
+		onStart()
+		shell()
+		onStop()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(duelCmd)
+}
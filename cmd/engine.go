@@ -17,41 +17,120 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 
 	"github.com/abperiasamy/chess"
-	"github.com/freeeve/uci"
+	"github.com/abperiasamy/pinata/uci"
 )
 
-// The shell initializes the engine upon entry.
+// Start the engine, looking under the games directory too.
 func newEngine(enginePath string) (*uci.Engine, error) {
-	_, err := exec.LookPath(gEngineBinary)
+	_, err := exec.LookPath(enginePath)
 	if err != nil { // Alternatively look under games dir.
-		path, err := exec.LookPath("/usr/games/" + gEngineBinary)
+		path, err := exec.LookPath("/usr/games/" + enginePath)
 		if err != nil {
-			fmt.Println("Unable to find " + gConsole.Bold(gConsole.Red(gEngineBinary)).String() + ". Please use `--engine` flag to choose a UCI compatible engine.")
-			os.Exit(1)
+			return nil, fmt.Errorf("unable to find %s", enginePath)
 		}
-		gEngineBinary = path
+		enginePath = path
 	}
 
 	eng, err := uci.NewEngine(enginePath)
 	if err != nil {
-		fmt.Println(gConsole.Red(err))
-		fmt.Println("Unable to initialize " + gConsole.Bold(gConsole.Red(gEngineBinary)).String() + ". Please use `--engine` flag to choose a UCI compatible engine.")
+		return nil, fmt.Errorf("unable to initialize %s, %v", enginePath, err)
+	}
+	return eng, nil
+}
+
+// Report the engine that could not be started and exit.
+func exitEngineError(err error) {
+	fmt.Println(gConsole.Bold(gConsole.Red(err)))
+	fmt.Println("Please use `--engine` flag to choose a UCI compatible engine.")
+	os.Exit(1)
+}
+
+// Start the engine and apply the strength settings. Exits if the engine is unusable.
+func startEngine() *uci.Engine {
+	eng, err := newEngine(gEngineBinary)
+	if err != nil {
+		exitEngineError(err)
+	}
+	eng.SendOption("Threads", "8")
+	if _, ok := eng.Option("Ponder"); ok && gPonder {
+		eng.SendOption("Ponder", true)
+	}
+	if err := setLevel(eng, gEngineElo, gEngineSkill); err != nil {
+		fmt.Println(err)
+		eng.Close()
 		os.Exit(1)
 	}
+	return eng
+}
 
-	return eng, err
+// Convert the engine's long algebraic move to the chosen notation for the given position.
+func lanToNotation(pos *chess.Position, moveLAN string) string {
+	for _, move := range pos.ValidMoves() {
+		if moveLAN == chess.Encoder.Encode(chess.LongAlgebraicNotation{}, pos, move) {
+			return formatMove(pos, move)
+		}
+	}
+	return moveLAN
 }
 
-// Engine's first move as white
-func engineMoveFirst(engine *uci.Engine, game *chess.Game) error {
-	results, err := engine.GoDepth(gEngineDepth, uci.HighestDepthOnly)
+// Search limits for the engine's next move. Clocks override the search budget.
+func engineLimits() uci.Limits {
+	if gClock != nil {
+		return gClock.limits()
+	}
+	return gSearchLimits
+}
+
+// Ask the engine for its move, running its clock during the search.
+// Returns nil results if the engine ran out of time.
+func engineSearch(engine *uci.Engine, game *chess.Game) (*uci.Results, error) {
+	if gClock != nil {
+		gClock.start(game.Position().Turn())
+	}
+	results, err := ponderSearch(engine, game)
 	if err != nil {
 		fmt.Println(err)
+		return nil, err
+	}
+	if gClock != nil && !gClock.stop() {
+		flagFall(game, game.Position().Turn())
+		return nil, nil
+	}
+	return results, nil
+}
+
+// Engine's move from the opening book while in book, otherwise searched.
+// Also reports whether the move came from the book.
+func engineReply(engine *uci.Engine, game *chess.Game) (*uci.Results, bool, error) {
+	if results := bookResults(engine, game); results != nil {
+		if gClock != nil { // Book moves take no time, but earn the increment.
+			gClock.start(game.Position().Turn())
+			gClock.stop()
+		}
+		return results, true, nil
+	}
+	results, err := engineSearch(engine, game)
+	return results, false, err
+}
+
+// Engine's move as printed at the engine prompt.
+func engineMoveLine(moveText string, fromBook bool) string {
+	if fromBook {
+		return enginePrompt() + moveText + gConsole.Faint(" (book move)").String()
+	}
+	return enginePrompt() + moveText
+}
+
+// Engine's first move as white
+func engineMoveFirst(engine *uci.Engine, game *chess.Game) error {
+	results, fromBook, err := engineReply(engine, game)
+	if results == nil {
 		return err
 	}
 
@@ -61,28 +140,67 @@ func engineMoveFirst(engine *uci.Engine, game *chess.Game) error {
 		return err
 	}
 
+	moveText := lanToNotation(game.Position(), results.BestMove)
 	err = game.Move(moveLAN)
 	if err != nil {
 		fmt.Println(err)
 		return err
 	}
+	if gClock != nil {
+		recordClock(game, humanColor().Other())
+	}
+	startPondering(engine, game, results.Ponder)
 
-	fmt.Println(enginePrompt() + chess.Encoder.Encode(chess.AlgebraicNotation{}, game.Position(), moveLAN))
+	fmt.Println(engineMoveLine(moveText, fromBook))
+	speakMove(game, true)
 	drawBoard(game)
 	return nil
 }
 
-// Send human move to engine and get a counter move in response
-func engineMoveNext(engine *uci.Engine, game *chess.Game, moveStr string) error {
-	err := game.MoveStr(moveStr)
+// Play the human move on the side to move and stop its clock.
+func humanMove(game *chess.Game, moveStr string) error {
+	color := game.Position().Turn()
+	move, err := decodeMove(game.Position(), moveStr)
+	if err != nil && isNaturalMove(moveStr) { // Worded like "knight to f3".
+		if move, err = resolveNaturalMove(game.Position(), moveStr); err != nil {
+			fmt.Println(err)
+			if !errors.Is(err, errNoLegalMove) {
+				return err
+			}
+		}
+	}
+	if err == nil {
+		err = game.Move(move)
+	}
 	if err != nil {
 		fmt.Println("Allowed moves:", gConsole.Bold(gConsole.Yellow(validMoves(game))))
 		return err
 	}
-	engine.SetFEN(game.FEN())
-	results, err := engine.GoDepth(gEngineDepth, uci.HighestDepthOnly)
-	if err != nil {
-		fmt.Println(err)
+	gRedoMoves = nil // A new move discards the moves taken back.
+	if gClock != nil {
+		if !gClock.stop() {
+			flagFall(game, color)
+			return nil
+		}
+		recordClock(game, color)
+	}
+	gJournal.sync(game)
+	speakMove(game, false)
+	if gHumanVsHuman {
+		drawBoard(game)
+	}
+	return nil
+}
+
+// Send human move to engine and get a counter move in response
+func engineMoveNext(engine *uci.Engine, game *chess.Game, moveStr string) error {
+	err := humanMove(game, moveStr)
+	if err != nil || game.Outcome() != chess.NoOutcome {
+		return err
+	}
+
+	results, fromBook, err := engineReply(engine, game)
+	if results == nil {
 		return err
 	}
 
@@ -92,19 +210,20 @@ func engineMoveNext(engine *uci.Engine, game *chess.Game, moveStr string) error
 		return err
 	}
 
-	// Only the valid moves list has the equivalent SAN move with tag pairs.
-	for _, move := range game.Position().ValidMoves() {
-		if moveLAN.String() == chess.Encoder.Encode(chess.LongAlgebraicNotation{}, game.Position(), move) {
-			fmt.Println(enginePrompt() + chess.Encoder.Encode(chess.AlgebraicNotation{}, game.Position(), move))
-		}
-	}
+	// Only the valid moves list has the equivalent move with tag pairs.
+	fmt.Println(engineMoveLine(lanToNotation(game.Position(), results.BestMove), fromBook))
 
 	err = game.Move(moveLAN)
 	if err != nil {
 		fmt.Println(err)
 		return err
 	}
+	if gClock != nil {
+		recordClock(game, humanColor().Other())
+	}
+	startPondering(engine, game, results.Ponder)
 
+	speakMove(game, true)
 	drawBoard(game)
 	return nil
 }
@@ -0,0 +1,203 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/abperiasamy/chess"
+	"github.com/abperiasamy/pinata/uci"
+	"github.com/logrusorgru/aurora"
+)
+
+const testStartFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// The test binary doubles as a fake engine, started by startFakeEngine,
+// and as another pinata holding a journal, started by startJournalHolder.
+func TestMain(m *testing.M) {
+	if os.Getenv("PINATA_FAKE_ENGINE") == "1" {
+		fakeEngine(os.Stdin, os.Stdout)
+		return
+	}
+	if filename := os.Getenv("PINATA_HOLD_JOURNAL"); filename != "" {
+		holdJournal(filename)
+		return
+	}
+	gConsole = aurora.NewAurora(false)
+	os.Exit(m.Run())
+}
+
+// Start the test binary as an engine, closed when the test ends.
+func startFakeEngine(t *testing.T) *uci.Engine {
+	t.Helper()
+	t.Setenv("PINATA_FAKE_ENGINE", "1")
+	eng, err := uci.NewEngine(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(eng.Close)
+	return eng
+}
+
+// Answer UCI commands, always playing the first of the legal moves in long
+// algebraic notation sorted alphabetically.
+func fakeEngine(in io.Reader, out io.Writer) {
+	pos := chess.NewGame().Position()
+	pondered := "" // Reply to the ponder search in progress.
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "uci":
+			fmt.Fprintln(out, "id name fake")
+			fmt.Fprintln(out, "option name UCI_LimitStrength type check default false")
+			fmt.Fprintln(out, "option name UCI_Elo type spin default 1350 min 1350 max 2850")
+			fmt.Fprintln(out, "option name Skill Level type spin default 20 min 0 max 20")
+			fmt.Fprintln(out, "uciok")
+		case "isready":
+			fmt.Fprintln(out, "readyok")
+		case "position":
+			pos = fakePosition(fields[1:])
+		case "go":
+			if len(fields) > 1 && fields[1] == "ponder" { // Answered on ponderhit or stop.
+				pondered = fakeSearch(pos)
+				continue
+			}
+			fmt.Fprint(out, fakeSearch(pos))
+		case "ponderhit", "stop":
+			fmt.Fprint(out, pondered)
+			pondered = ""
+		case "quit":
+			return
+		}
+	}
+}
+
+// Engine output of a search of the position, pondering on the first reply.
+func fakeSearch(pos *chess.Position) string {
+	moves := sortedMoves(pos)
+	if len(moves) == 0 {
+		return "bestmove (none)\n"
+	}
+	best := moves[0]
+	m, _ := chess.LongAlgebraicNotation{}.Decode(pos, best)
+	for _, valid := range pos.ValidMoves() {
+		if valid.String() == m.String() {
+			if replies := sortedMoves(pos.Update(valid)); len(replies) > 0 {
+				return fmt.Sprintf("info depth 1 score cp 0 pv %s %s\nbestmove %s ponder %s\n", best, replies[0], best, replies[0])
+			}
+		}
+	}
+	return fmt.Sprintf("info depth 1 score cp 0 pv %s\nbestmove %s\n", best, best)
+}
+
+// Position of the arguments of the UCI "position" command.
+func fakePosition(args []string) *chess.Position {
+	game := chess.NewGame()
+	if len(args) >= 7 && args[0] == "fen" {
+		fen, err := chess.FEN(strings.Join(args[1:7], " "))
+		if err != nil {
+			return game.Position()
+		}
+		game, args = chess.NewGame(fen), args[7:]
+	} else if len(args) > 0 && args[0] == "startpos" {
+		args = args[1:]
+	}
+	if len(args) > 0 && args[0] == "moves" {
+		for _, lan := range args[1:] {
+			m, err := chess.LongAlgebraicNotation{}.Decode(game.Position(), lan)
+			if err != nil || game.Move(m) != nil {
+				break
+			}
+		}
+	}
+	return game.Position()
+}
+
+// Legal moves of the position in long algebraic notation, sorted.
+func sortedMoves(pos *chess.Position) []string {
+	var moves []string
+	for _, m := range pos.ValidMoves() {
+		moves = append(moves, chess.Encoder.Encode(chess.LongAlgebraicNotation{}, pos, m))
+	}
+	sort.Strings(moves)
+	return moves
+}
+
+// Start a game from the FEN.
+func gameFromFEN(t *testing.T, fen string) *chess.Game {
+	t.Helper()
+	option, err := chess.FEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chess.NewGame(option, chess.UseNotation(chess.AlgebraicNotation{}))
+}
+
+// Play the moves, given in long algebraic notation, from the start position.
+func playLAN(t *testing.T, moves string) *chess.Game {
+	t.Helper()
+	game := chess.NewGame(chess.UseNotation(chess.AlgebraicNotation{}))
+	for _, lan := range strings.Fields(moves) {
+		m, err := chess.LongAlgebraicNotation{}.Decode(game.Position(), lan)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := game.Move(m); err != nil {
+			t.Fatalf("%s: %v", lan, err)
+		}
+	}
+	return game
+}
+
+// Moves of the game in long algebraic notation.
+func gameLAN(game *chess.Game) string {
+	return movesLAN(game.Positions()[0], game.Moves())
+}
+
+// Moves played from the position in long algebraic notation.
+func movesLAN(pos *chess.Position, moves []*chess.Move) string {
+	var lans []string
+	for _, m := range moves {
+		lans = append(lans, chess.Encoder.Encode(chess.LongAlgebraicNotation{}, pos, m))
+		pos = pos.Update(m)
+	}
+	return strings.Join(lans, " ")
+}
+
+func TestEngineMoveNext(t *testing.T) {
+	engine := startFakeEngine(t)
+	game := playLAN(t, "")
+	if err := engineMoveNext(engine, game, "e4"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := gameLAN(game), "e2e4 a7a5"; got != want {
+		t.Errorf("game after e4 = %q, want %q", got, want)
+	}
+	if err := engineMoveNext(engine, game, "Ke3"); err == nil {
+		t.Error("engineMoveNext played the illegal Ke3")
+	}
+}
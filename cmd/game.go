@@ -18,8 +18,8 @@ package cmd
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -39,21 +39,14 @@ func GetTagPair(game *chess.Game, key string) string {
 
 // Start a game from a PGN file
 func loadPGN(filename string) *chess.Game {
-	pgnDat, err := ioutil.ReadFile(filename)
-	if err != nil {
+	if _, err := os.Stat(filename); err != nil {
 		fmt.Println(gConsole.Bold("Unable to read " + gConsole.Red(filename).String() + "."))
 		return nil
 	}
 
-	pgn, err := chess.PGN(strings.NewReader(string(pgnDat)))
+	game, notes, err := readPGN(filename)
 	if err != nil {
-		fmt.Println(gConsole.Bold(gConsole.Red(filename)), "is not a valid PGN file.")
-		return nil
-	}
-
-	game := chess.NewGame(pgn)
-	if game == nil {
-		fmt.Println("Unable to initialize a new game from " + gConsole.Bold(gConsole.Red(filename)).String() + ".")
+		fmt.Println(gConsole.Bold(gConsole.Red(filename)), "is not a valid PGN file,", err)
 		return nil
 	}
 
@@ -67,8 +60,19 @@ func loadPGN(filename string) *chess.Game {
 		return nil
 	}
 
+	// Engine match games have no human side to resume.
+	if GetTagPair(game, "Event") == gMatchEvent {
+		fmt.Println(gConsole.Bold(gConsole.Red(filename)), "is an engine match game.")
+		return nil
+	}
+
 	// Load previous settings.
-	if tpBlack == "Human" { // Human is black.
+	gHumanVsHuman = GetTagPair(game, "Duel") == "true"
+	if gHumanVsHuman { // Both players are human.
+		gWhiteName, gBlackName = tpWhite, tpBlack
+		fmt.Println(gConsole.Bold(gConsole.Yellow(gWhiteName)).String() + " (White) is playing " +
+			gConsole.Bold(gConsole.Yellow(gBlackName)).String() + " (Black).")
+	} else if tpBlack == "Human" { // Human is black.
 		gHumanIsBlack = true
 		gEngineBinary = tpWhite // Use the same engine as before.
 		fmt.Println("You are playing " + gConsole.Bold(gConsole.Yellow("Black")).String() +
@@ -79,6 +83,40 @@ func loadPGN(filename string) *chess.Game {
 		fmt.Println("You are playing " + gConsole.Bold(gConsole.Yellow("White")).String() +
 			" against " + gConsole.Bold(gConsole.Yellow(gEngineBinary)).String() + ".")
 	}
+	gTakebacks, _ = strconv.Atoi(GetTagPair(game, "Takebacks"))
+	gHints, _ = strconv.Atoi(GetTagPair(game, "Hints"))
+	gPeeks, _ = strconv.Atoi(GetTagPair(game, "Peeks"))
+	gPlayedVisual = GetTagPair(game, "Visual") == "true"
+	gMemChecks = strings.Fields(GetTagPair(game, "MemChecks"))
+	gRedoMoves = nil
+	gTermination = GetTagPair(game, "Termination")
+	if level := GetTagPair(game, "EngineLevel"); level != "" {
+		elo, skill, err := parseLevel(strings.Fields(level))
+		if err != nil {
+			fmt.Println(gConsole.Bold(gConsole.Red(filename)), "has an invalid EngineLevel,", err)
+			return nil
+		}
+		gEngineElo, gEngineSkill = elo, skill
+	}
+
+	// Resume the clocks where they were left.
+	gClock, gClockHistory = nil, nil
+	if tc := GetTagPair(game, "TimeControl"); tc != "" && tc != "-" {
+		clock, err := clockFromTag(tc, GetTagPair(game, "TimeDelay"))
+		if err != nil {
+			fmt.Println(err)
+			return nil
+		}
+		gClock = clock
+		for i, pos := range game.Positions()[:len(game.Moves())] {
+			clk := moveClock(notes[i])
+			gClockHistory = append(gClockHistory, clk)
+			if clk >= 0 {
+				gClock.remaining[pos.Turn()] = clk
+			}
+		}
+	}
+	gMoveNotes = notes // Keep the comments, NAGs and variations when saving.
 
 	return game
 }
@@ -93,21 +131,64 @@ func savePGN(game *chess.Game, filename string) error {
 	defer file.Close()
 
 	// Generate PGN content.
-	game.AddTagPair("Annotator", "pinata")
-	curTime := time.Now()
-	curDate := fmt.Sprintf("%d-%02d-%02d", curTime.Year(), curTime.Month(), curTime.Day())
-	game.AddTagPair("Date", curDate)
-	game.AddTagPair("Result", game.Outcome().String())
-	if humanColor() == chess.White {
+	addGameTags(game)
+	if start := game.Positions()[0].String(); start != chess.NewGame().FEN() { // Set up with /fen.
+		game.AddTagPair("SetUp", "1")
+		game.AddTagPair("FEN", start)
+	}
+	if gHumanVsHuman {
+		game.AddTagPair("White", gWhiteName)
+		game.AddTagPair("Black", gBlackName)
+		game.AddTagPair("Duel", "true")
+	} else if humanColor() == chess.White {
 		game.AddTagPair("White", "Human")
 		game.AddTagPair("Black", gEngineBinary)
 	} else {
 		game.AddTagPair("White", gEngineBinary)
 		game.AddTagPair("Black", "Human")
 	}
+	if gTakebacks > 0 {
+		game.AddTagPair("Takebacks", strconv.Itoa(gTakebacks))
+	}
+	if gHints > 0 {
+		game.AddTagPair("Hints", strconv.Itoa(gHints))
+	}
+	if gPeeks > 0 {
+		game.AddTagPair("Peeks", strconv.Itoa(gPeeks))
+	}
+	if gPlayedVisual {
+		game.AddTagPair("Visual", "true")
+	}
+	if len(gMemChecks) > 0 {
+		game.AddTagPair("MemChecks", strings.Join(gMemChecks, " "))
+	}
+	if gTermination != "" {
+		game.AddTagPair("Termination", gTermination)
+	}
+	if game.Method() != chess.NoMethod && gTermination == "" { // Resignations and draw offers are not seen in the moves.
+		game.AddTagPair("Method", game.Method().String())
+	}
+	if opening := gameOpening(game); opening != nil {
+		game.AddTagPair("ECO", opening.eco)
+		game.AddTagPair("Opening", opening.name)
+	}
+	if levelLimited() {
+		game.AddTagPair("EngineLevel", levelString(gEngineElo, gEngineSkill))
+		if gEngineElo > 0 { // Engine is rated as a player.
+			game.AddTagPair(humanColor().Other().Name()+"Elo", strconv.Itoa(gEngineElo))
+		}
+	}
+
+	// Record the time control, the clock after every move goes with the notes.
+	if gClock != nil {
+		game.AddTagPair("TimeControl", gClock.timeControl())
+		if gClock.delay > 0 {
+			game.AddTagPair("TimeDelay", strconv.Itoa(int(gClock.delay.Seconds())))
+		}
+	}
 
 	// Save the engine name.
-	_, err = file.WriteString(game.String() + "\n")
+	_, err = file.WriteString(encodeAnnotatedPGN(game, gameNotes(game)) + "\n")
 	if err != nil {
 		fmt.Println("Unable to save the game to", gConsole.Bold(gConsole.Red(filename)))
 		return err
@@ -116,37 +197,67 @@ func savePGN(game *chess.Game, filename string) error {
 	return nil // Success
 }
 
+// Tag the game as annotated by pinata with today's date and its result.
+func addGameTags(game *chess.Game) {
+	game.AddTagPair("Annotator", "pinata")
+	curTime := time.Now()
+	curDate := fmt.Sprintf("%d-%02d-%02d", curTime.Year(), curTime.Month(), curTime.Day())
+	game.AddTagPair("Date", curDate)
+	game.AddTagPair("Result", game.Outcome().String())
+}
+
 func drawBoard(game *chess.Game) {
-	if !gVisual {
+	if !gVisual && !revealBoard(game) {
 		return // playing blind
 	}
+	fmt.Print(boardString(game))
+}
 
-	if gHumanIsBlack { // Rotate the board, black facing the human.
-		fmt.Print(game.Position().Board().DrawForBlack())
-	} else {
-		fmt.Print(game.Position().Board().Draw())
+// The board as drawn for the human.
+func boardString(game *chess.Game) string {
+	facingBlack := gHumanIsBlack
+	if gHumanVsHuman { // Face the player to move.
+		facingBlack = game.Position().Turn() == chess.Black
+	}
+	if facingBlack { // Rotate the board, black facing the human.
+		return game.Position().Board().DrawForBlack()
 	}
+	return game.Position().Board().Draw()
+}
+
+// How the game ended, preferring the termination recorded by pinata.
+func gameMethod(game *chess.Game) string {
+	if gTermination != "" {
+		return gTermination
+	}
+	return game.Method().String()
 }
 
 func isGameOver(game *chess.Game) bool {
+	var result string
 	switch game.Outcome() {
 	case chess.NoOutcome:
 		return false
 	case chess.Draw:
-		fmt.Println(gConsole.Bold(gConsole.Yellow("Game Draw")).String() +
-			" (" + gConsole.Bold(game.Method().String()).String() + ")")
+		result = "Game Draw"
 	case chess.WhiteWon:
-		fmt.Println(gConsole.Bold(gConsole.Yellow("White Won")).String() +
-			" (" + gConsole.Bold(game.Method().String()).String() + ")")
+		result = "White Won"
 	case chess.BlackWon:
-		fmt.Println(gConsole.Bold(gConsole.Yellow("Black Won")).String() +
-			" (" + gConsole.Bold(game.Method().String()).String() + ")")
+		result = "Black Won"
 	default:
 		panic(game.Outcome()) // should never happen.
 	}
+	fmt.Println(gConsole.Bold(gConsole.Yellow(result)).String() +
+		" (" + gConsole.Bold(gameMethod(game)).String() + ")")
+	speak(result + ", " + spokenMethod(gameMethod(game)))
 	return true // The end.
 }
 
+// Reset the prompt's move counter to the full move number of the current position.
+func syncMoveCount(game *chess.Game) {
+	gMoveCount = fullMoveNumber(game.Position())
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func humanColor() chess.Color {
@@ -156,12 +267,12 @@ func humanColor() chess.Color {
 	return chess.White
 }
 
-// Readline completion of all the valid moves left.
+// Readline completion of all the valid moves left. A spaced suffix like the
+// descriptive " ch" is left out, it would end the word being completed.
 func validMovesConstructor() func(string) []string {
 	return func(string) (moves []string) {
 		for _, move := range gGame.Position().ValidMoves() {
-			moveSAN := chess.Encoder.Encode(chess.AlgebraicNotation{}, gGame.Position(), move)
-			moves = append(moves, moveSAN)
+			moves = append(moves, strings.Fields(formatMove(gGame.Position(), move))[0])
 		}
 		return moves
 	}
@@ -170,7 +281,7 @@ func validMovesConstructor() func(string) []string {
 // Readline completion of all the valid moves left.
 func validMoves(game *chess.Game) (moves string) {
 	for _, move := range game.Position().ValidMoves() {
-		moves += " " + chess.Encoder.Encode(chess.AlgebraicNotation{}, game.Position(), move)
+		moves += " " + formatMove(game.Position(), move)
 	}
 	return moves
 }
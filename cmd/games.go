@@ -0,0 +1,280 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// gamesCmd browses the library of saved games
+var gamesCmd = &cobra.Command{
+	Use:   "games",
+	Short: "Browse the library of your saved games.",
+	Long: `Every game is saved to the library under an id made of the date and time it
+was first saved, like 20201018-143005. Ids may be shortened to any unique prefix.`,
+}
+
+var gamesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the saved games, filtered by result, engine and date.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		onStart()
+		games, err := filterGames(libraryGames())
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		listGames(games)
+	},
+}
+
+var gamesShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show the tags, moves and final position of a saved game.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		onStart()
+		if err := showGame(args[0]); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+var gamesDeleteCmd = &cobra.Command{
+	Use:   "delete <id>...",
+	Short: "Delete saved games.",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		onStart()
+		for _, id := range args {
+			filename, err := findLibraryGame(id)
+			if err == nil {
+				err = os.Remove(filename)
+			}
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			fmt.Println("Deleted", gConsole.Bold(gConsole.Yellow(gameID(filename))))
+		}
+	},
+}
+
+var gamesExportCmd = &cobra.Command{
+	Use:   "export [id]...",
+	Short: "Export saved games as one PGN, all the filtered games unless named.",
+	Run: func(cmd *cobra.Command, args []string) {
+		onStart()
+		if err := exportGames(args); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(gamesCmd)
+	gamesCmd.AddCommand(gamesListCmd, gamesShowCmd, gamesDeleteCmd, gamesExportCmd)
+
+	for _, c := range []*cobra.Command{gamesListCmd, gamesExportCmd} {
+		c.Flags().StringVar(&gGamesResult, "result", "", "only your wins, losses, draws or unfinished games")
+		c.Flags().StringVar(&gGamesOpponent, "opponent", "", "only games against an engine or player whose name contains this")
+		c.Flags().StringVar(&gGamesSince, "since", "", "only games played on or after this date (YYYY-MM-DD)")
+		c.Flags().StringVar(&gGamesUntil, "until", "", "only games played on or before this date (YYYY-MM-DD)")
+	}
+	gamesExportCmd.Flags().StringVarP(&gGamesOutput, "output", "o", "", "write the PGN to this file instead of the screen")
+}
+
+// A library game with its id.
+type libraryGame struct {
+	id       string
+	filename string
+	game     *chess.Game
+}
+
+// Results of the --result filter.
+var gResultFilters = map[string]string{
+	"win": "win", "wins": "win", "loss": "loss", "losses": "loss",
+	"draw": "draw", "draws": "draw", "unfinished": "unfinished",
+}
+
+var gDateRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+// Whether a PGN date like "2020.10.18" or "2020-10-18" is within the filter.
+func dateInRange(date, since, until string) bool {
+	date = strings.ReplaceAll(date, ".", "-")
+	return (since == "" || date >= since) && (until == "" || date <= until)
+}
+
+// Read the library games passing the --result, --opponent, --since and --until filters.
+func filterGames(filenames []string) ([]libraryGame, error) {
+	result, ok := gResultFilters[strings.ToLower(gGamesResult)]
+	if !ok && gGamesResult != "" {
+		return nil, fmt.Errorf("invalid result %q, use win, loss, draw or unfinished", gGamesResult)
+	}
+	for _, date := range []string{gGamesSince, gGamesUntil} {
+		if date != "" && !gDateRegex.MatchString(date) {
+			return nil, fmt.Errorf("invalid date %q, use YYYY-MM-DD", date)
+		}
+	}
+
+	var games []libraryGame
+	for _, filename := range filenames {
+		game, err := readLibraryGame(filename)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		switch {
+		case result == "unfinished" && game.Outcome() != chess.NoOutcome:
+			continue
+		case result != "" && result != "unfinished" && humanResult(game) != result:
+			continue
+		case !strings.Contains(strings.ToLower(gameOpponent(game)), strings.ToLower(gGamesOpponent)):
+			continue
+		case !dateInRange(GetTagPair(game, "Date"), gGamesSince, gGamesUntil):
+			continue
+		}
+		games = append(games, libraryGame{gameID(filename), filename, game})
+	}
+	return games, nil
+}
+
+// Print the games as a table.
+func listGames(games []libraryGame) {
+	if len(games) == 0 {
+		fmt.Println("No games in", gConsole.Bold(gConsole.Yellow(libraryDir())))
+		return
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Id", "Date", "Side", "Opponent", "Level", "Result", "Moves", "Board"})
+	for _, g := range games {
+		side := "-"
+		if color := humanSide(g.game); color != chess.NoColor {
+			side = color.Name()
+		}
+		level := GetTagPair(g.game, "EngineLevel")
+		if level == "" {
+			level = "-"
+		}
+		board := "blind"
+		if GetTagPair(g.game, "Visual") == "true" {
+			board = "visual"
+		}
+		result := GetTagPair(g.game, "Result")
+		if r := humanResult(g.game); r != "" {
+			result += " " + r
+		}
+		table.Append([]string{
+			g.id,
+			GetTagPair(g.game, "Date"),
+			side,
+			gameOpponent(g.game),
+			level,
+			result,
+			strconv.Itoa(fullMoves(g.game)),
+			board,
+		})
+	}
+	table.Render()
+}
+
+// Print a saved game with its tags, moves in the chosen notation and final position.
+func showGame(id string) error {
+	filename, err := findLibraryGame(id)
+	if err != nil {
+		return err
+	}
+	game, err := readLibraryGame(filename)
+	if err != nil {
+		return err
+	}
+	notation, err := parseNotation(gNotation)
+	if err != nil {
+		return err
+	}
+	gNotation = notation
+
+	fmt.Println(gConsole.Bold(gConsole.Yellow(gameID(filename))), filename)
+	for _, tag := range game.TagPairs() {
+		fmt.Println(tag.Key+":", tag.Value)
+	}
+	fmt.Println()
+	if len(game.Moves()) > 0 {
+		fmt.Println(formatMoves(game))
+	}
+	if humanSide(game) == chess.Black {
+		fmt.Print(game.Position().Board().DrawForBlack())
+	} else {
+		fmt.Print(game.Position().Board().Draw())
+	}
+	return nil
+}
+
+// Write the named games, or all the filtered games, as one PGN.
+func exportGames(ids []string) error {
+	var filenames []string
+	if len(ids) == 0 {
+		games, err := filterGames(libraryGames())
+		if err != nil {
+			return err
+		}
+		for _, g := range games {
+			filenames = append(filenames, g.filename)
+		}
+	}
+	for _, id := range ids {
+		filename, err := findLibraryGame(id)
+		if err != nil {
+			return err
+		}
+		filenames = append(filenames, filename)
+	}
+
+	var out io.Writer = os.Stdout
+	if gGamesOutput != "" {
+		file, err := os.Create(gGamesOutput)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	for _, filename := range filenames {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, strings.TrimSpace(string(data))+"\n")
+	}
+	if gGamesOutput != "" {
+		fmt.Println("Exported", gConsole.Bold(gConsole.Yellow(len(filenames))), "game(s) to", gConsole.Bold(gConsole.Yellow(gGamesOutput)))
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abperiasamy/chess"
+)
+
+const gGlimpseSeconds = 3 // Default duration of a /glimpse.
+
+// Seconds requested by "/glimpse [seconds]".
+func glimpseDuration(cmd string) (time.Duration, error) {
+	args := strings.Fields(cmd)
+	if len(args) < 2 {
+		return gGlimpseSeconds * time.Second, nil
+	}
+	seconds, err := strconv.ParseFloat(args[1], 64)
+	if err != nil || seconds <= 0 {
+		return 0, fmt.Errorf("invalid glimpse duration %q", args[1])
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// Show the board for a moment, then erase it from the terminal.
+func glimpse(game *chess.Game, d time.Duration) {
+	gPeeks++
+	board := boardString(game)
+	fmt.Print(board)
+	time.Sleep(d)
+	// Move the cursor back up over the board and clear to the end of the screen.
+	fmt.Printf("\033[%dA\033[J", strings.Count(board, "\n"))
+}
+
+// Whether the board is revealed at this move by --reveal-until or --reveal-every.
+func revealBoard(game *chess.Game) bool {
+	moveNum := fullMoveNumber(game.Position())
+	if gRevealUntil > 0 && moveNum <= gRevealUntil {
+		return true
+	}
+	return gRevealEvery > 0 && moveNum%gRevealEvery == 0
+}
@@ -0,0 +1,67 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGlimpseDuration(t *testing.T) {
+	tests := []struct {
+		cmd string
+		d   time.Duration // 0 for an error.
+	}{
+		{"/glimpse", gGlimpseSeconds * time.Second},
+		{"/glimpse 5", 5 * time.Second},
+		{"/glimpse 0.5", 500 * time.Millisecond},
+		{"/glimpse 0", 0},
+		{"/glimpse -1", 0},
+		{"/glimpse soon", 0},
+	}
+	for _, test := range tests {
+		d, err := glimpseDuration(test.cmd)
+		if d != test.d || (err != nil) != (test.d == 0) {
+			t.Errorf("glimpseDuration(%q) = %v, %v, want %v", test.cmd, d, err, test.d)
+		}
+	}
+}
+
+func TestRevealBoard(t *testing.T) {
+	defer func(until, every int) { gRevealUntil, gRevealEvery = until, every }(gRevealUntil, gRevealEvery)
+	tests := []struct {
+		until, every int
+		moves        string
+		reveal       bool
+	}{
+		{0, 0, "", false},
+		{2, 0, "e2e4 e7e5", true}, // Move 2.
+		{2, 0, "e2e4 e7e5 g1f3 b8c6", false},
+		{0, 3, "e2e4 e7e5 g1f3 b8c6", true}, // Move 3.
+		{0, 3, "e2e4 e7e5 g1f3 b8c6 f1c4 g8f6", false},
+		{1, 4, "e2e4 e7e5 g1f3 b8c6", false},
+		{1, 4, "e2e4 e7e5 g1f3 b8c6 f1c4 g8f6", true}, // Move 4.
+		{1, 4, "e2e4 e7e5 g1f3 b8c6 f1c4 g8f6 e1g1 f8e7", false},
+	}
+	for _, test := range tests {
+		gRevealUntil, gRevealEvery = test.until, test.every
+		if reveal := revealBoard(playLAN(t, test.moves)); reveal != test.reveal {
+			t.Errorf("revealBoard after %q with until %d and every %d = %v, want %v",
+				test.moves, test.until, test.every, reveal, test.reveal)
+		}
+	}
+}
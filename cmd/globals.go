@@ -17,7 +17,10 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package cmd
 
 import (
+	"time"
+
 	"github.com/abperiasamy/chess"
+	"github.com/abperiasamy/pinata/uci"
 	"github.com/logrusorgru/aurora"
 )
 
@@ -25,27 +28,84 @@ import (
 const (
 	gVersion      = "1.11"
 	gGameFilename = "pinata.pgn"
+	gMatchEvent   = "pinata match" // Event tag of engine match games.
 )
 
 // Global defaults. Avoid global variables as much as possible.
 var (
-	gCfgFile        string
-	gGamePath       string
-	gEngineBinary   string
-	gLichessAuthTok string
-	gEngineDepth    int
-	gHumanIsBlack   bool
-	gVisual         bool
-	gNoColor        bool
-	gLightBg        bool
-	gConsole        aurora.Aurora
-	gMoveCount      int = 1 // Increment on every black's move.
-
-	gGame *chess.Game
+	gCfgFile          string
+	gGamePath         string
+	gEngineBinary     string
+	gBookPath         string
+	gLibraryDir       string // Directory of saved games, empty for the default.
+	gLichessAuthTok   string
+	gLichessTokenFile string // File holding the lichess.org access-token, kept out of the config.
+	gKeyBindings      string // Key bindings of the prompt, vi or emacs.
+	gHistorySize      int    // Commands kept in the history file, 0 for no history.
+	gHistoryMoves     bool   // Keep the moves in the history too.
+	gEngineDepth      int
+	gEngineMoveTime   int // milliseconds
+	gEngineNodes      int
+	gEngineElo        int // 0 for full strength
+	gEngineSkill      int // -1 for full strength
+	gTimeControl      string
+	gHumanIsBlack     bool
+	gVisual           bool
+	gPlayedVisual     bool // The board was shown at some point of the game.
+	gRevealUntil      int  // Show the board up to this move, 0 for never.
+	gRevealEvery      int  // Show the board every n moves, 0 for never.
+	gMemCheckEvery    int  // Ask for a memory check every n moves, 0 for never.
+	gPonder           bool
+	gSpeak            string // Text-to-speech command, empty for silence.
+	gSpeakHuman       bool
+	gNotation         string // Notation of the moves shown and typed, see gNotations.
+	gHumanVsHuman     bool
+	gWhiteName        string // Player names in a human vs human game.
+	gBlackName        string
+	gNoColor          bool
+	gLightBg          bool
+	gConsole          aurora.Aurora
+	gMoveCount        int = 1 // Increment on every black's move.
+	gTakebacks        int     // Number of moves taken back with /undo.
+	gHints            int     // Number of engine hints requested with /hint.
+	gPeeks            int     // Number of board queries with /piece, /where, /list and /moves.
+
+	gMatchEngine1  string // Engine match settings.
+	gMatchEngine2  string
+	gMatchGames    int
+	gMatchOpenings string
+	gMatchPGN      string
+	gMatchSPRT     string // Elo bounds "elo0,elo1" of the SPRT, empty to play all the games.
+
+	gGamesResult   string // Game library filters.
+	gGamesOpponent string
+	gGamesSince    string
+	gGamesUntil    string
+	gGamesOutput   string // PGN file of the exported games, empty for the screen.
+
+	gAnalysisOutput string // Annotated PGN file written by analyze, empty for the analysis directory.
+
+	gSearchLimits uci.Limits // Search budget of every engine move, unless the clock is running.
+
+	gGame         *chess.Game
+	gRedoMoves    []*chess.Move   // Moves taken back, available to /redo.
+	gBook         *openingBook    // nil when playing without an opening book.
+	gClock        *chessClock     // nil when playing without a clock.
+	gClockHistory []time.Duration // Remaining time after every ply, -1 if unknown.
+	gPonderMove   string          // Human's reply the engine is pondering on, in long algebraic notation.
+	gTermination  string          // Termination not expressible as a chess.Method, like "time forfeit".
+	gOpening      *chessOpening   // Opening last announced to the players.
+	gMemChecks    []string        // Memory check accuracy as "move:percent".
+	gMemCheckMove int             // Move of the last memory check.
+	gJournal      *journal        // Journal of the game being played, nil without one.
+	gLibraryFile  string          // Library file of the game being played, empty until saved.
+	gMoveNotes    []pgnMove       // Comments, NAGs and variations of the loaded game's plies.
 )
 
 // Called before starting the shell.
 func initGlobals() {
 	// Use for color printing
 	gConsole = aurora.NewAurora(!gNoColor)
+
+	gSearchLimits = flagSearchLimits()
 }
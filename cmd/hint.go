@@ -0,0 +1,105 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+	"github.com/abperiasamy/pinata/uci"
+)
+
+const gMaxHints = 5 // Upper bound on the candidate moves shown by /hint.
+
+// Number of candidate moves requested by "/hint [n]".
+func hintCount(cmd string) (int, error) {
+	args := strings.Fields(cmd)
+	if len(args) < 2 {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(args[1])
+	if err != nil || n < 1 || n > gMaxHints {
+		return 0, fmt.Errorf("hint count must be between 1 and %d", gMaxHints)
+	}
+	return n, nil
+}
+
+// Keep only the deepest principal variation for every MultiPV rank.
+func topMoves(results *uci.Results) []uci.ScoreResult {
+	deepest := map[int]uci.ScoreResult{}
+	ranks := 0
+	for _, r := range results.Results {
+		if len(r.BestMoves) == 0 {
+			continue
+		}
+		if r.MultiPV > ranks {
+			ranks = r.MultiPV
+		}
+		if prev, ok := deepest[r.MultiPV]; !ok || r.Depth >= prev.Depth {
+			deepest[r.MultiPV] = r
+		}
+	}
+
+	var top []uci.ScoreResult
+	for rank := 0; rank <= ranks; rank++ {
+		if r, ok := deepest[rank]; ok {
+			top = append(top, r)
+		}
+	}
+	return top
+}
+
+// Format the engine score from the side to move's point of view.
+func formatScore(r uci.ScoreResult) string {
+	if r.Mate {
+		return "#" + strconv.Itoa(r.Score)
+	}
+	return fmt.Sprintf("%+.2f", float64(r.Score)/100)
+}
+
+// Ask the engine for its top n moves in the current position and print them in SAN.
+func showHints(engine *uci.Engine, game *chess.Game, n int) error {
+	engine.SendOption("MultiPV", n)
+	defer engine.SendOption("MultiPV", 1)
+	if levelLimited() { // Hints come from the engine at full strength.
+		sendLevel(engine, 0, -1)
+		defer sendLevel(engine, gEngineElo, gEngineSkill)
+	}
+
+	engine.SetFEN(game.FEN())
+	results, err := engine.Go(gSearchLimits)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	gHints++
+
+	top := topMoves(results)
+	if len(top) == 0 { // Engine did not report any lines, fallback to the best move.
+		fmt.Println(gConsole.Bold(gConsole.Yellow(lanToNotation(game.Position(), results.BestMove))))
+		return nil
+	}
+	for i, r := range top {
+		if i == n {
+			break
+		}
+		fmt.Println(strconv.Itoa(i+1)+".", gConsole.Bold(gConsole.Yellow(lanToNotation(game.Position(), r.BestMoves[0]))), "("+formatScore(r)+")")
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/abperiasamy/pinata/uci"
+)
+
+func TestHintCount(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want int
+	}{
+		{"/hint", 1},
+		{"/hint 1", 1},
+		{"/hint 3", 3},
+		{"/hint 5", gMaxHints},
+	}
+	for _, test := range tests {
+		if got, err := hintCount(test.cmd); err != nil || got != test.want {
+			t.Errorf("hintCount(%q) = %d, %v, want %d", test.cmd, got, err, test.want)
+		}
+	}
+	for _, cmd := range []string{"/hint 0", "/hint 6", "/hint -1", "/hint all"} {
+		if _, err := hintCount(cmd); err == nil {
+			t.Errorf("hintCount(%q) succeeded, want an error", cmd)
+		}
+	}
+}
+
+func TestTopMoves(t *testing.T) {
+	line := func(depth, rank int, move string) uci.ScoreResult {
+		return uci.ScoreResult{Depth: depth, MultiPV: rank, BestMoves: []string{move}}
+	}
+	tests := []struct {
+		name    string
+		results []uci.ScoreResult
+		want    []string // Best move of each line in order.
+	}{
+		{"ranked", []uci.ScoreResult{line(10, 2, "d2d4"), line(10, 1, "e2e4"), line(10, 3, "c2c4")}, []string{"e2e4", "d2d4", "c2c4"}},
+		{"deepest kept", []uci.ScoreResult{line(9, 1, "d2d4"), line(10, 1, "e2e4"), line(8, 1, "c2c4")}, []string{"e2e4"}},
+		{"latest of a depth", []uci.ScoreResult{line(10, 1, "d2d4"), line(10, 1, "e2e4")}, []string{"e2e4"}},
+		{"without MultiPV", []uci.ScoreResult{line(5, 0, "g1f3")}, []string{"g1f3"}},
+		{"missing rank", []uci.ScoreResult{line(10, 1, "e2e4"), line(10, 3, "c2c4")}, []string{"e2e4", "c2c4"}},
+		{"no line", []uci.ScoreResult{{Depth: 10, MultiPV: 1}}, nil},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, r := range topMoves(&uci.Results{Results: test.results}) {
+			got = append(got, r.BestMoves[0])
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: topMoves = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestFormatScore(t *testing.T) {
+	tests := []struct {
+		result uci.ScoreResult
+		want   string
+	}{
+		{uci.ScoreResult{Score: 35}, "+0.35"},
+		{uci.ScoreResult{Score: -120}, "-1.20"},
+		{uci.ScoreResult{Score: 0}, "+0.00"},
+		{uci.ScoreResult{Score: 3, Mate: true}, "#3"},
+		{uci.ScoreResult{Score: -2, Mate: true}, "#-2"},
+	}
+	for _, test := range tests {
+		if got := formatScore(test.result); got != test.want {
+			t.Errorf("formatScore(%+v) = %q, want %q", test.result, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chzyer/readline"
+)
+
+// History file next to the config file in use, empty when history is off.
+func historyPath() string {
+	if gHistorySize <= 0 {
+		return ""
+	}
+	filename := filepath.Join(filepath.Dir(gCfgFile), "history")
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		fmt.Println("Unable to keep the command history,", err)
+		return ""
+	}
+	return filename
+}
+
+// Readline history limit, where readline takes -1 for no history.
+func historyLimit() int {
+	if gHistorySize <= 0 {
+		return -1
+	}
+	return gHistorySize
+}
+
+// Whether the command goes in the history. Moves are left out unless --history-moves
+// is given, so scrolling back does not replay the game to a blind player.
+func keepInHistory(cmd string) bool {
+	if cmd == "" || gHistorySize <= 0 {
+		return false
+	}
+	return strings.HasPrefix(cmd, "/") || cmd == "resign" || gHistoryMoves
+}
+
+// Keep the command in the history.
+func saveHistory(l *readline.Instance, cmd string) {
+	if keepInHistory(cmd) {
+		l.SaveHistory(cmd)
+	}
+}
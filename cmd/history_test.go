@@ -0,0 +1,76 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeepInHistory(t *testing.T) {
+	defer func(size int, moves bool) { gHistorySize, gHistoryMoves = size, moves }(gHistorySize, gHistoryMoves)
+	tests := []struct {
+		size  int
+		moves bool
+		cmd   string
+		keep  bool
+	}{
+		{500, false, "/hint", true},
+		{500, false, "/save game.pgn", true},
+		{500, false, "resign", true},
+		{500, false, "e4", false},
+		{500, false, "knight to f3", false},
+		{500, false, "", false},
+		{500, true, "e4", true},
+		{500, true, "", false},
+		{0, false, "/hint", false},
+		{0, true, "e4", false},
+	}
+	for _, test := range tests {
+		gHistorySize, gHistoryMoves = test.size, test.moves
+		if keep := keepInHistory(test.cmd); keep != test.keep {
+			t.Errorf("keepInHistory(%q) with size %d and moves %v = %v, want %v",
+				test.cmd, test.size, test.moves, keep, test.keep)
+		}
+	}
+}
+
+func TestHistoryLimit(t *testing.T) {
+	defer func(size int) { gHistorySize = size }(gHistorySize)
+	for size, limit := range map[int]int{500: 500, 1: 1, 0: -1, -5: -1} {
+		gHistorySize = size
+		if got := historyLimit(); got != limit {
+			t.Errorf("historyLimit with size %d = %d, want %d", size, got, limit)
+		}
+	}
+	gHistorySize = 0
+	if filename := historyPath(); filename != "" {
+		t.Errorf("historyPath without history = %q, want none", filename)
+	}
+
+	// The history follows the --config file.
+	gHistorySize = 500
+	config := useConfig(t)
+	want := filepath.Join(filepath.Dir(config), "history")
+	if filename := historyPath(); filename != want {
+		t.Errorf("historyPath = %q, want %q next to the config file", filename, want)
+	}
+	if _, err := os.Stat(filepath.Dir(want)); err != nil {
+		t.Errorf("history directory not created: %v", err)
+	}
+}
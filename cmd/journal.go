@@ -0,0 +1,416 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abperiasamy/chess"
+	"github.com/chzyer/readline"
+)
+
+// The journal keeps the game being played crash-safe. It is a text file
+// appended with one record per line as the game goes on:
+//
+//	start <fen>            the game starts from this position
+//	move <lan> <clock ms>  a ply, with the mover's clock or -1 without one
+//	undo <plies>           plies taken back
+//	state <json>           settings and counters, see journalState
+//
+// The journal is removed when pinata exits normally, so a journal left
+// behind is an unfinished game to resume. The pinata writing a journal
+// holds an exclusive lock on it, which the system releases if it dies.
+type journal struct {
+	file  *os.File
+	start string   // Start position journaled last.
+	plies []string // Moves journaled so far, in long algebraic notation.
+	state string   // State journaled last.
+}
+
+// Settings and counters of a game that are not moves.
+type journalState struct {
+	Flags        map[string]string `json:"flags"`
+	Depth        int               `json:"depth,omitempty"` // Search limits changed by /limit.
+	MoveTime     int64             `json:"movetime,omitempty"`
+	Nodes        int               `json:"nodes,omitempty"`
+	TimeControl  string            `json:"timecontrol,omitempty"`
+	TimeDelay    string            `json:"timedelay,omitempty"`
+	Takebacks    int               `json:"takebacks,omitempty"`
+	Hints        int               `json:"hints,omitempty"`
+	Peeks        int               `json:"peeks,omitempty"`
+	MemChecks    []string          `json:"memchecks,omitempty"`
+	MemCheckMove int               `json:"memcheckmove,omitempty"`
+	Visual       bool              `json:"visual,omitempty"`  // The board was shown.
+	LibraryFile  string            `json:"library,omitempty"` // Library file saved to.
+}
+
+// Flags restored on resume. Display preferences stay as given for this run.
+var gJournalFlags = []string{"engine", "black", "depth", "movetime", "nodes", "elo", "skill",
+	"human-vs-human", "white-name", "black-name", "ponder", "book", "notation", "visual",
+	"reveal-until", "reveal-every", "memcheck-every", "speak", "speak-human"}
+
+// Directory of the journals, next to the default config file.
+func journalDir() string {
+	return filepath.Join(filepath.Dir(defaultConfigPath()), "journal")
+}
+
+// Open a new journal for this run, named after the time and the process.
+func newJournal() *journal {
+	if err := os.MkdirAll(journalDir(), 0755); err != nil {
+		fmt.Println("Unable to keep a journal of the game,", err)
+		return nil
+	}
+	name := time.Now().Format("20060102-150405") + "-" + strconv.Itoa(os.Getpid()) + ".journal"
+	return openJournal(filepath.Join(journalDir(), name))
+}
+
+// Open a journal for appending and lock it.
+func openJournal(filename string) *journal {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		fmt.Println("Unable to keep a journal of the game,", err)
+		return nil
+	}
+	if err := lockFile(file); err != nil {
+		fmt.Println("Unable to keep a journal of the game, it is locked by another pinata,", err)
+		file.Close()
+		return nil
+	}
+	return &journal{file: file}
+}
+
+// Append the records and flush them to disk. Every record is written
+// whole in a single append, a crash loses at most the records not synced.
+func (j *journal) append(records []string) {
+	for _, record := range records {
+		if _, err := j.file.WriteString(record + "\n"); err != nil {
+			fmt.Println("Unable to write the journal,", err)
+			return
+		}
+	}
+	j.file.Sync()
+}
+
+// Close the journal and remove it, the game needs no resume.
+func (j *journal) remove() {
+	if j == nil {
+		return
+	}
+	j.file.Close()
+	os.Remove(j.file.Name())
+}
+
+// Current settings and counters.
+func currentJournalState() journalState {
+	state := journalState{
+		Flags:        make(map[string]string),
+		Depth:        gSearchLimits.Depth,
+		MoveTime:     gSearchLimits.MoveTime.Milliseconds(),
+		Nodes:        gSearchLimits.Nodes,
+		Takebacks:    gTakebacks,
+		Hints:        gHints,
+		Peeks:        gPeeks,
+		MemChecks:    gMemChecks,
+		MemCheckMove: gMemCheckMove,
+		Visual:       gPlayedVisual,
+		LibraryFile:  gLibraryFile,
+	}
+	for _, name := range gJournalFlags {
+		state.Flags[name] = gRootFlags.Lookup(name).Value.String()
+	}
+	if gClock != nil {
+		state.TimeControl = gClock.timeControl()
+		if gClock.delay > 0 {
+			state.TimeDelay = strconv.Itoa(int(gClock.delay.Seconds()))
+		}
+	}
+	return state
+}
+
+// Bring the journal up to date with the game: a new start position, moves
+// taken back, new moves and changed settings.
+func (j *journal) sync(game *chess.Game) {
+	if j == nil {
+		return
+	}
+	var records []string
+	positions := game.Positions()
+	if start := positions[0].String(); start != j.start {
+		records = append(records, "start "+start)
+		j.start, j.plies = start, nil
+	}
+
+	moves := game.Moves()
+	lans := make([]string, len(moves))
+	for i, m := range moves {
+		lans[i] = chess.Encoder.Encode(chess.LongAlgebraicNotation{}, positions[i], m)
+	}
+	common := 0
+	for common < len(j.plies) && common < len(lans) && j.plies[common] == lans[common] {
+		common++
+	}
+	if common < len(j.plies) {
+		records = append(records, "undo "+strconv.Itoa(len(j.plies)-common))
+	}
+	for i := common; i < len(lans); i++ {
+		clk := "-1"
+		if i < len(gClockHistory) && gClockHistory[i] >= 0 {
+			clk = strconv.FormatInt(gClockHistory[i].Milliseconds(), 10)
+		}
+		records = append(records, "move "+lans[i]+" "+clk)
+	}
+	j.plies = lans
+
+	if data, err := json.Marshal(currentJournalState()); err == nil && string(data) != j.state {
+		records = append(records, "state "+string(data))
+		j.state = string(data)
+	}
+	if len(records) > 0 {
+		j.append(records)
+	}
+}
+
+// A journal read back for resuming.
+type journalReplay struct {
+	filename string
+	start    string
+	plies    []string
+	clocks   []time.Duration
+	state    journalState
+	stateRaw string
+}
+
+// Read a journal. A last record cut short by a crash is ignored.
+func readJournal(filename string) (*journalReplay, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	r := &journalReplay{filename: filename, start: chess.NewGame().FEN()}
+	lines := strings.Split(string(data), "\n")
+	for _, line := range lines[:len(lines)-1] { // Past the last newline is an incomplete record.
+		record := strings.SplitN(line, " ", 2)
+		if len(record) != 2 {
+			return nil, fmt.Errorf("invalid journal record %q", line)
+		}
+		switch record[0] {
+		case "start":
+			r.start, r.plies, r.clocks = record[1], nil, nil
+		case "move":
+			fields := strings.Fields(record[1])
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("invalid journal record %q", line)
+			}
+			ms, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid journal record %q", line)
+			}
+			clk := time.Duration(ms) * time.Millisecond
+			if ms < 0 {
+				clk = -1
+			}
+			r.plies = append(r.plies, fields[0])
+			r.clocks = append(r.clocks, clk)
+		case "undo":
+			n, err := strconv.Atoi(record[1])
+			if err != nil || n > len(r.plies) {
+				return nil, fmt.Errorf("invalid journal record %q", line)
+			}
+			r.plies, r.clocks = r.plies[:len(r.plies)-n], r.clocks[:len(r.clocks)-n]
+		case "state":
+			var state journalState
+			if err := json.Unmarshal([]byte(record[1]), &state); err != nil {
+				return nil, fmt.Errorf("invalid journal record %q", line)
+			}
+			r.state, r.stateRaw = state, record[1]
+		default:
+			return nil, fmt.Errorf("invalid journal record %q", line)
+		}
+	}
+	return r, nil
+}
+
+// Replay the journaled moves from the start position.
+func (r *journalReplay) game() (*chess.Game, error) {
+	fen, err := chess.FEN(r.start)
+	if err != nil {
+		return nil, err
+	}
+	game := chess.NewGame(fen, chess.UseNotation(chess.AlgebraicNotation{}))
+	for _, lan := range r.plies {
+		move, err := (chess.LongAlgebraicNotation{}).Decode(game.Position(), lan)
+		if err == nil {
+			err = game.Move(move)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid move %q in the journal", lan)
+		}
+	}
+	return game, nil
+}
+
+// Opponent of the journaled game as shown to the player.
+func (r *journalReplay) opponent() string {
+	if r.state.Flags["human-vs-human"] == "true" {
+		return r.state.Flags["white-name"] + " vs " + r.state.Flags["black-name"]
+	}
+	side := "White"
+	if r.state.Flags["black"] == "true" {
+		side = "Black"
+	}
+	return side + " against " + r.state.Flags["engine"]
+}
+
+// Restore the game, side, engine and settings of the journal. Flags given
+// on the command line take precedence over the journaled ones.
+func resumeGame(filename string) error {
+	if journalInUse(filename) {
+		return fmt.Errorf("the game is being played by another pinata")
+	}
+	r, err := readJournal(filename)
+	if err != nil {
+		return err
+	}
+	game, err := r.game()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range gJournalFlags {
+		flag := gRootFlags.Lookup(name)
+		if value, ok := r.state.Flags[name]; ok && !flag.Changed {
+			if err := flag.Value.Set(value); err != nil {
+				return fmt.Errorf("invalid %s in the journal: %v", name, err)
+			}
+		}
+	}
+	initGlobals() // Search limits follow the restored flags.
+	if r.state.Depth > 0 || r.state.MoveTime > 0 || r.state.Nodes > 0 {
+		gSearchLimits.Depth = r.state.Depth
+		gSearchLimits.MoveTime = time.Duration(r.state.MoveTime) * time.Millisecond
+		gSearchLimits.Nodes = r.state.Nodes
+	}
+	gTakebacks, gHints, gPeeks = r.state.Takebacks, r.state.Hints, r.state.Peeks
+	gMemChecks, gMemCheckMove = r.state.MemChecks, r.state.MemCheckMove
+	gPlayedVisual, gLibraryFile = r.state.Visual, r.state.LibraryFile
+	if gLibraryFile != "" { // Keep the annotations of the library game.
+		if _, notes, err := readPGN(gLibraryFile); err == nil {
+			gMoveNotes = notes
+		}
+	}
+
+	// Resume the clocks where they were left.
+	gClock, gClockHistory = nil, nil
+	if r.state.TimeControl != "" {
+		if gClock, err = clockFromTag(r.state.TimeControl, r.state.TimeDelay); err != nil {
+			return err
+		}
+		gClockHistory = r.clocks
+		for i, pos := range game.Positions()[:len(r.clocks)] {
+			if r.clocks[i] >= 0 {
+				gClock.remaining[pos.Turn()] = r.clocks[i]
+			}
+		}
+	}
+
+	gGame = game
+	gJournal = openJournal(filename)
+	if gJournal != nil {
+		gJournal.start, gJournal.plies, gJournal.state = r.start, r.plies, r.stateRaw
+	}
+	fmt.Println("Resuming your game as", gConsole.Bold(gConsole.Yellow(r.opponent())).String()+",",
+		"move", gConsole.Bold(gConsole.Yellow(fullMoveNumber(game.Position()))).String()+".")
+	return nil
+}
+
+// Whether the journal belongs to another pinata still running, which
+// holds its lock.
+func journalInUse(filename string) bool {
+	if gJournal != nil && gJournal.file.Name() == filename {
+		return false
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return false
+	}
+	defer file.Close() // Releases the lock taken to test it.
+	return lockFile(file) != nil
+}
+
+// Journals of unfinished games, the most recent first.
+func unfinishedJournals() []string {
+	filenames, _ := filepath.Glob(filepath.Join(journalDir(), "*.journal"))
+	var unfinished []string
+	for _, filename := range filenames {
+		if !journalInUse(filename) {
+			unfinished = append(unfinished, filename)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(unfinished)))
+	return unfinished
+}
+
+// Find a journal by its file name or its name in the journal directory.
+func findJournal(name string) string {
+	if _, err := os.Stat(name); err == nil {
+		return name
+	}
+	filename := filepath.Join(journalDir(), name)
+	if !strings.HasSuffix(filename, ".journal") {
+		filename += ".journal"
+	}
+	return filename
+}
+
+// Offer to resume the most recent unfinished game at startup.
+func offerResume(l *readline.Instance) {
+	journals := unfinishedJournals()
+	if len(journals) == 0 {
+		return
+	}
+	r, err := readJournal(journals[0])
+	if err != nil {
+		return
+	}
+	game, err := r.game()
+	if err != nil {
+		return
+	}
+	fmt.Println("You have an unfinished game as", gConsole.Bold(gConsole.Yellow(r.opponent())).String()+",",
+		"move", gConsole.Bold(gConsole.Yellow(fullMoveNumber(game.Position()))).String()+".")
+	l.SetPrompt("Resume it? [y]es, [n]o or [d]iscard: ")
+	answer, err := l.Readline()
+	if err != nil {
+		return
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes", "":
+		if err := resumeGame(journals[0]); err != nil {
+			fmt.Println("Unable to resume the game,", err)
+		}
+	case "d", "discard":
+		os.Remove(journals[0])
+	}
+}
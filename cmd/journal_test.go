@@ -0,0 +1,229 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Write the journal records to a file in a temporary directory.
+func writeJournal(t *testing.T, data string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "test.journal")
+	if err := ioutil.WriteFile(filename, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestJournalRoundTrip(t *testing.T) {
+	defer func(clockHistory []time.Duration, takebacks int) {
+		gClockHistory, gTakebacks = clockHistory, takebacks
+	}(gClockHistory, gTakebacks)
+	gClockHistory = nil
+
+	filename := filepath.Join(t.TempDir(), "test.journal")
+	j := openJournal(filename)
+	if j == nil {
+		t.Fatal("openJournal failed")
+	}
+	defer j.file.Close()
+
+	game := playLAN(t, "e2e4 e7e5 g1f3")
+	j.sync(game)
+	j.sync(game) // Nothing new to journal.
+	game = playLAN(t, "e2e4 e7e5 d2d4")
+	gClockHistory = []time.Duration{time.Minute, 2 * time.Minute, -1}
+	gTakebacks = 1
+	j.sync(game)
+
+	r, err := readJournal(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r.plies, []string{"e2e4", "e7e5", "d2d4"}) {
+		t.Errorf("journaled plies = %q, want e2e4 e7e5 d2d4", r.plies)
+	}
+	if !reflect.DeepEqual(r.clocks, []time.Duration{-1, -1, -1}) {
+		t.Errorf("journaled clocks = %v, want none", r.clocks)
+	}
+	if r.state.Takebacks != 1 || r.state.Flags["engine"] != gEngineBinary {
+		t.Errorf("journaled state = %+v, want 1 takeback and engine %q", r.state, gEngineBinary)
+	}
+	replayed, err := r.game()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := gameLAN(replayed); got != "e2e4 e7e5 d2d4" {
+		t.Errorf("replayed game = %q, want e2e4 e7e5 d2d4", got)
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []string
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		if strings.HasPrefix(line, "state ") {
+			line = "state" // Checked by the replay above.
+		}
+		records = append(records, line)
+	}
+	want := []string{"start " + testStartFEN, "move e2e4 -1", "move e7e5 -1", "move g1f3 -1", "state",
+		"undo 1", "move d2d4 -1", "state"}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("journal records = %q, want %q", records, want)
+	}
+}
+
+func TestReadJournal(t *testing.T) {
+	fen := "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"
+	tests := []struct {
+		data   string
+		start  string
+		plies  []string
+		clocks []time.Duration
+	}{
+		{"", testStartFEN, nil, nil},
+		{"move e2e4 60000\nmove e7e5 -1\n", testStartFEN, []string{"e2e4", "e7e5"}, []time.Duration{time.Minute, -1}},
+		{"move e2e4 60000\nmove e7e5 -1\nmove g1", testStartFEN, []string{"e2e4", "e7e5"}, []time.Duration{time.Minute, -1}}, // Cut short.
+		{"move e2e4 60000\nmove e7e5 -1\nundo 2\n", testStartFEN, []string{}, []time.Duration{}},
+		{"move e2e4 -1\nstart " + fen + "\nmove e1d1 -1\n", fen, []string{"e1d1"}, []time.Duration{-1}},
+		{"start " + fen + "\nmove e2e4 -1\nundo 1\nmove e1f1 59000\n", fen, []string{"e1f1"}, []time.Duration{59 * time.Second}},
+	}
+	for _, test := range tests {
+		r, err := readJournal(writeJournal(t, test.data))
+		if err != nil {
+			t.Errorf("readJournal(%q) failed: %v", test.data, err)
+			continue
+		}
+		if r.start != test.start || !reflect.DeepEqual(r.plies, test.plies) || !reflect.DeepEqual(r.clocks, test.clocks) {
+			t.Errorf("readJournal(%q) = %q %q %v, want %q %q %v", test.data,
+				r.start, r.plies, r.clocks, test.start, test.plies, test.clocks)
+		}
+	}
+
+	for _, data := range []string{"move\n", "move e2e4\n", "move e2e4 soon\n", "undo 1\n", "undo x\n",
+		"state {\n", "resign now\n"} {
+		if _, err := readJournal(writeJournal(t, data)); err == nil {
+			t.Errorf("readJournal(%q) succeeded, want an error", data)
+		}
+	}
+	if _, err := readJournal(filepath.Join(t.TempDir(), "missing.journal")); err == nil {
+		t.Error("readJournal of a missing file succeeded")
+	}
+}
+
+func TestJournalReplay(t *testing.T) {
+	r, err := readJournal(writeJournal(t, "move e2e4 -1\nmove e2e4 -1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.game(); err == nil {
+		t.Error("replaying an illegal move succeeded")
+	}
+
+	tests := []struct {
+		flags    map[string]string
+		opponent string
+	}{
+		{map[string]string{"engine": "stockfish", "black": "false"}, "White against stockfish"},
+		{map[string]string{"engine": "lc0", "black": "true"}, "Black against lc0"},
+		{map[string]string{"human-vs-human": "true", "white-name": "Ann", "black-name": "Bob"}, "Ann vs Bob"},
+	}
+	for _, test := range tests {
+		r := journalReplay{state: journalState{Flags: test.flags}}
+		if opponent := r.opponent(); opponent != test.opponent {
+			t.Errorf("opponent of %v = %q, want %q", test.flags, opponent, test.opponent)
+		}
+	}
+}
+
+// Keep the journal open and locked until stdin is closed.
+func holdJournal(filename string) {
+	j := openJournal(filename)
+	if j == nil {
+		os.Exit(1)
+	}
+	fmt.Println("locked")
+	ioutil.ReadAll(os.Stdin)
+	j.file.Close()
+}
+
+// Start the test binary as another pinata holding the journal. Closing
+// the returned writer makes it exit.
+func startJournalHolder(t *testing.T, filename string) (io.WriteCloser, *exec.Cmd) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), "PINATA_HOLD_JOURNAL="+filename)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		stdin.Close()
+		cmd.Wait()
+	})
+	if line, err := bufio.NewReader(stdout).ReadString('\n'); err != nil || line != "locked\n" {
+		t.Fatalf("the journal holder did not lock the journal: %q, %v", line, err)
+	}
+	return stdin, cmd
+}
+
+func TestJournalInUse(t *testing.T) {
+	filename := writeJournal(t, "move e2e4 -1\n")
+	if journalInUse(filename) {
+		t.Fatal("a journal nobody holds is in use")
+	}
+
+	stdin, holder := startJournalHolder(t, filename)
+	if !journalInUse(filename) {
+		t.Error("a journal locked by another process is not in use")
+	}
+	if err := resumeGame(filename); err == nil || !strings.Contains(err.Error(), "another pinata") {
+		t.Errorf("resuming a locked journal = %v, want it refused", err)
+	}
+	if j := openJournal(filename); j != nil {
+		j.file.Close()
+		t.Error("opened a journal locked by another process")
+	}
+
+	stdin.Close()
+	if err := holder.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if journalInUse(filename) {
+		t.Error("a journal is still in use after its holder exited")
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/abperiasamy/pinata/uci"
+)
+
+// Engine strength is limited by an Elo rating (0 for none) and/or a
+// skill level (-1 for none). Without either the engine plays at full strength.
+
+// Human readable strength level, also saved as the EngineLevel PGN tag.
+func levelString(elo, skill int) string {
+	var level []string
+	if elo > 0 {
+		level = append(level, "Elo "+strconv.Itoa(elo))
+	}
+	if skill >= 0 {
+		level = append(level, "Skill "+strconv.Itoa(skill))
+	}
+	return strings.Join(level, " ")
+}
+
+// Parse a level like "elo 1500", "skill 5", "elo 1500 skill 5" or "off".
+func parseLevel(args []string) (elo, skill int, err error) {
+	elo, skill = 0, -1
+	if len(args) == 1 && strings.ToLower(args[0]) == "off" {
+		return elo, skill, nil
+	}
+	if len(args) == 0 || len(args)%2 != 0 {
+		return elo, skill, fmt.Errorf("level must be [elo N] [skill N] or off")
+	}
+	for i := 0; i < len(args); i += 2 {
+		n, err := strconv.Atoi(args[i+1])
+		if err != nil || n < 0 {
+			return 0, -1, fmt.Errorf("invalid level %q", args[i+1])
+		}
+		switch strings.ToLower(args[i]) {
+		case "elo":
+			elo = n
+		case "skill":
+			skill = n
+		default:
+			return elo, skill, fmt.Errorf("unknown level %q, use elo or skill", args[i])
+		}
+	}
+	return elo, skill, nil
+}
+
+// Check the level against the options advertised by the engine.
+func validateLevel(engine *uci.Engine, elo, skill int) error {
+	check := func(name string, value int) error {
+		opt, ok := engine.Option(name)
+		if !ok || opt.Type != "spin" {
+			return fmt.Errorf("%s does not support the %q option", engine.Name, name)
+		}
+		if value < opt.Min || value > opt.Max {
+			return fmt.Errorf("%s must be between %d and %d", name, opt.Min, opt.Max)
+		}
+		return nil
+	}
+
+	if elo < 0 || skill < -1 { // -1 is the unset skill of --skill.
+		return fmt.Errorf("elo and skill must not be negative")
+	}
+	if elo > 0 {
+		if err := check("UCI_Elo", elo); err != nil {
+			return err
+		}
+	}
+	if skill >= 0 {
+		if err := check("Skill Level", skill); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Send the strength limits to the engine, restoring full strength for the unset ones.
+func sendLevel(engine *uci.Engine, elo, skill int) {
+	if _, ok := engine.Option("UCI_LimitStrength"); ok {
+		engine.SendOption("UCI_LimitStrength", elo > 0)
+	}
+	if elo > 0 {
+		engine.SendOption("UCI_Elo", elo)
+	}
+	if opt, ok := engine.Option("Skill Level"); ok {
+		if skill >= 0 {
+			engine.SendOption("Skill Level", skill)
+		} else {
+			engine.SendOption("Skill Level", opt.Default)
+		}
+	}
+}
+
+// Validate, send and remember the engine's strength level.
+func setLevel(engine *uci.Engine, elo, skill int) error {
+	if err := validateLevel(engine, elo, skill); err != nil {
+		return err
+	}
+	sendLevel(engine, elo, skill)
+	gEngineElo, gEngineSkill = elo, skill
+	return nil
+}
+
+// Is the engine playing below its full strength?
+func levelLimited() bool {
+	return gEngineElo > 0 || gEngineSkill >= 0
+}
+
+// Print the engine's current strength level.
+func showLevel() {
+	if !levelLimited() {
+		fmt.Println("Engine is playing at", gConsole.Bold(gConsole.Yellow("full strength")))
+		return
+	}
+	fmt.Println("Engine is playing at", gConsole.Bold(gConsole.Yellow(levelString(gEngineElo, gEngineSkill))))
+}
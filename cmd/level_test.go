@@ -0,0 +1,92 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		args       string
+		elo, skill int
+	}{
+		{"elo 1500", 1500, -1},
+		{"skill 5", 0, 5},
+		{"skill 0", 0, 0},
+		{"ELO 1800 Skill 10", 1800, 10},
+		{"off", 0, -1},
+		{"OFF", 0, -1},
+	}
+	for _, test := range tests {
+		elo, skill, err := parseLevel(strings.Fields(test.args))
+		if err != nil || elo != test.elo || skill != test.skill {
+			t.Errorf("parseLevel(%q) = %d, %d, %v, want %d, %d", test.args, elo, skill, err, test.elo, test.skill)
+		}
+	}
+
+	for _, args := range []string{"", "elo", "elo -5", "skill -1", "elo strong", "depth 5", "off elo", "skill 5 elo"} {
+		if _, _, err := parseLevel(strings.Fields(args)); err == nil {
+			t.Errorf("parseLevel(%q) succeeded, want an error", args)
+		}
+	}
+}
+
+func TestLevelString(t *testing.T) {
+	tests := []struct {
+		elo, skill int
+		want       string
+	}{
+		{1500, -1, "Elo 1500"},
+		{0, 5, "Skill 5"},
+		{0, 0, "Skill 0"},
+		{1800, 10, "Elo 1800 Skill 10"},
+		{0, -1, ""},
+	}
+	for _, test := range tests {
+		if got := levelString(test.elo, test.skill); got != test.want {
+			t.Errorf("levelString(%d, %d) = %q, want %q", test.elo, test.skill, got, test.want)
+		}
+		if elo, skill, err := parseLevel(strings.Fields(test.want)); test.want != "" && (err != nil || elo != test.elo || skill != test.skill) {
+			t.Errorf("parseLevel(%q) = %d, %d, %v, want %d, %d", test.want, elo, skill, err, test.elo, test.skill)
+		}
+	}
+}
+
+func TestValidateLevel(t *testing.T) {
+	engine := startFakeEngine(t)
+	tests := []struct {
+		elo, skill int
+		ok         bool
+	}{
+		{0, -1, true},
+		{1500, -1, true},
+		{1350, 0, true},
+		{2850, 20, true},
+		{1000, -1, false}, // Below the engine's UCI_Elo.
+		{3000, -1, false},
+		{0, 21, false},
+		{-5, -1, false},
+		{0, -3, false},
+	}
+	for _, test := range tests {
+		if err := validateLevel(engine, test.elo, test.skill); (err == nil) != test.ok {
+			t.Errorf("validateLevel(%d, %d) = %v, want ok %v", test.elo, test.skill, err, test.ok)
+		}
+	}
+}
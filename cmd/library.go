@@ -0,0 +1,192 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abperiasamy/chess"
+)
+
+// Directory of the game library, next to the default config file unless --library is given.
+func libraryDir() string {
+	if gLibraryDir != "" {
+		return gLibraryDir
+	}
+	return filepath.Join(filepath.Dir(defaultConfigPath()), "games")
+}
+
+// Id of a library game, its file name without the extension.
+func gameID(filename string) string {
+	return strings.TrimSuffix(filepath.Base(filename), ".pgn")
+}
+
+// New library file named after the current time, like 20201018-143005.pgn.
+func newLibraryFile() (string, error) {
+	if err := os.MkdirAll(libraryDir(), 0755); err != nil {
+		return "", err
+	}
+	stem := filepath.Join(libraryDir(), time.Now().Format("20060102-150405"))
+	filename := stem + ".pgn"
+	for i := 2; ; i++ { // Games saved within the same second.
+		if _, err := os.Stat(filename); os.IsNotExist(err) {
+			return filename, nil
+		}
+		filename = stem + "-" + strconv.Itoa(i) + ".pgn"
+	}
+}
+
+// Save the game to the library, overwriting its earlier save if any.
+// Returns the file the game is saved to.
+func saveGame(game *chess.Game) (string, error) {
+	if gLibraryFile == "" {
+		filename, err := newLibraryFile()
+		if err != nil {
+			fmt.Println("Unable to save the game to the library,", err)
+			return "", err
+		}
+		gLibraryFile = filename
+	}
+	if err := savePGN(game, gLibraryFile); err != nil {
+		return "", err
+	}
+	fmt.Println("Game saved to", gConsole.Bold(gConsole.Red(gLibraryFile)))
+	return gLibraryFile, nil
+}
+
+// Whether the file is in the game library.
+func inLibrary(filename string) bool {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return false
+	}
+	dir, err := filepath.Abs(libraryDir())
+	return err == nil && filepath.Dir(abs) == dir
+}
+
+// Library games, oldest first.
+func libraryGames() []string {
+	filenames, _ := filepath.Glob(filepath.Join(libraryDir(), "*.pgn"))
+	sort.Strings(filenames)
+	return filenames
+}
+
+// Most recent library game other than the one being played, empty if none.
+func lastLibraryGame() string {
+	games := libraryGames()
+	for i := len(games) - 1; i >= 0; i-- {
+		if games[i] != gLibraryFile {
+			return games[i]
+		}
+	}
+	return ""
+}
+
+// Find a library game by its id or a unique prefix of it.
+func findLibraryGame(id string) (string, error) {
+	id = gameID(id)
+	var found []string
+	for _, filename := range libraryGames() {
+		if gameID(filename) == id {
+			return filename, nil
+		}
+		if strings.HasPrefix(gameID(filename), id) {
+			found = append(found, filename)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("no game %q in the library", id)
+	case 1:
+		return found[0], nil
+	}
+	return "", fmt.Errorf("%q matches %d games, give more of the id", id, len(found))
+}
+
+// A PGN file to load, trying the library when no such file exists.
+func findGameFile(name string) string {
+	if _, err := os.Stat(name); err == nil {
+		return name
+	}
+	if filename, err := findLibraryGame(name); err == nil {
+		return filename
+	}
+	return name
+}
+
+// Read a library game without touching the game being played.
+func readLibraryGame(filename string) (*chess.Game, error) {
+	if _, err := os.Stat(filename); err != nil {
+		return nil, err
+	}
+	game, _, err := readPGN(filename)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a valid PGN file, %v", filename, err)
+	}
+	return game, nil
+}
+
+// Human's side of a saved game, empty for a human vs human game.
+func humanSide(game *chess.Game) chess.Color {
+	switch {
+	case GetTagPair(game, "Duel") == "true":
+		return chess.NoColor
+	case GetTagPair(game, "Black") == "Human":
+		return chess.Black
+	}
+	return chess.White
+}
+
+// Engine of a saved game, or the players of a human vs human game.
+func gameOpponent(game *chess.Game) string {
+	switch humanSide(game) {
+	case chess.NoColor:
+		return GetTagPair(game, "White") + " vs " + GetTagPair(game, "Black")
+	case chess.Black:
+		return GetTagPair(game, "White")
+	}
+	return GetTagPair(game, "Black")
+}
+
+// Result of a saved game for the human: win, loss, draw, or empty if the
+// game is unfinished or between humans.
+func humanResult(game *chess.Game) string {
+	result := GetTagPair(game, "Result")
+	side := humanSide(game)
+	switch {
+	case side == chess.NoColor:
+		return ""
+	case result == "1/2-1/2":
+		return "draw"
+	case result != "1-0" && result != "0-1":
+		return ""
+	case (result == "1-0") == (side == chess.White):
+		return "win"
+	}
+	return "loss"
+}
+
+// Number of full moves in the game.
+func fullMoves(game *chess.Game) int {
+	return (len(game.Moves()) + 1) / 2
+}
@@ -0,0 +1,144 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abperiasamy/chess"
+)
+
+// Use a library in a temporary directory holding the games of the ids.
+func useLibrary(t *testing.T, ids ...string) string {
+	saved := gLibraryDir
+	gLibraryDir = t.TempDir()
+	t.Cleanup(func() { gLibraryDir = saved })
+	for _, id := range ids {
+		if err := ioutil.WriteFile(filepath.Join(gLibraryDir, id+".pgn"), []byte("1. e4 *\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return gLibraryDir
+}
+
+func TestFindLibraryGame(t *testing.T) {
+	dir := useLibrary(t, "20201018-143005", "20201018-143005-2", "20201018-150000", "20201105-090000")
+	tests := []struct {
+		id, want string // Empty want for an error.
+	}{
+		{"20201018-143005", "20201018-143005"}, // Exact, though also a prefix of -2.
+		{"20201018-143005.pgn", "20201018-143005"},
+		{"20201018-143005-2", "20201018-143005-2"},
+		{"20201018-15", "20201018-150000"},
+		{"202011", "20201105-090000"},
+		{"20201018", ""}, // Ambiguous.
+		{"2020", ""},
+		{"2019", ""},
+	}
+	for _, test := range tests {
+		filename, err := findLibraryGame(test.id)
+		want := ""
+		if test.want != "" {
+			want = filepath.Join(dir, test.want+".pgn")
+		}
+		if filename != want || (err == nil) != (want != "") {
+			t.Errorf("findLibraryGame(%q) = %q, %v, want %q", test.id, filename, err, want)
+		}
+	}
+	if _, err := findLibraryGame("20201018"); err == nil || !strings.Contains(err.Error(), "matches 3 games") {
+		t.Errorf("findLibraryGame of an ambiguous id failed with %v, want 3 matches", err)
+	}
+
+	if got, want := findGameFile("202011"), filepath.Join(dir, "20201105-090000.pgn"); got != want {
+		t.Errorf("findGameFile(\"202011\") = %q, want %q", got, want)
+	}
+	if got := findGameFile("missing.pgn"); got != "missing.pgn" {
+		t.Errorf("findGameFile(\"missing.pgn\") = %q, want it unchanged", got)
+	}
+	if !inLibrary(filepath.Join(dir, "20201018-150000.pgn")) || inLibrary("pinata.pgn") {
+		t.Error("inLibrary does not tell the library games")
+	}
+}
+
+func TestLastLibraryGame(t *testing.T) {
+	defer func(file string) { gLibraryFile = file }(gLibraryFile)
+	gLibraryFile = ""
+	useLibrary(t)
+	if last := lastLibraryGame(); last != "" {
+		t.Errorf("lastLibraryGame of an empty library = %q, want none", last)
+	}
+
+	dir := useLibrary(t, "20201018-143005", "20201105-090000")
+	if last, want := lastLibraryGame(), filepath.Join(dir, "20201105-090000.pgn"); last != want {
+		t.Errorf("lastLibraryGame = %q, want %q", last, want)
+	}
+	gLibraryFile = filepath.Join(dir, "20201105-090000.pgn") // Being played.
+	if last, want := lastLibraryGame(), filepath.Join(dir, "20201018-143005.pgn"); last != want {
+		t.Errorf("lastLibraryGame while playing the latest = %q, want %q", last, want)
+	}
+}
+
+func TestNewLibraryFile(t *testing.T) {
+	dir := useLibrary(t)
+	first, err := newLibraryFile()
+	if err != nil || filepath.Dir(first) != dir || !strings.HasSuffix(first, ".pgn") {
+		t.Fatalf("newLibraryFile = %q, %v, want a PGN file in %s", first, err, dir)
+	}
+	if err := ioutil.WriteFile(first, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	second, err := newLibraryFile()
+	if err != nil || second == first {
+		t.Errorf("newLibraryFile in the same second = %q, %v, want a new file", second, err)
+	}
+}
+
+// Game with the tag pairs given as key, value, key, value...
+func taggedGame(tags ...string) *chess.Game {
+	game := chess.NewGame()
+	for i := 0; i < len(tags); i += 2 {
+		game.AddTagPair(tags[i], tags[i+1])
+	}
+	return game
+}
+
+func TestHumanResult(t *testing.T) {
+	tests := []struct {
+		game             *chess.Game
+		result, opponent string
+	}{
+		{taggedGame("White", "Human", "Black", "stockfish", "Result", "1-0"), "win", "stockfish"},
+		{taggedGame("White", "Human", "Black", "stockfish", "Result", "0-1"), "loss", "stockfish"},
+		{taggedGame("White", "lc0", "Black", "Human", "Result", "0-1"), "win", "lc0"},
+		{taggedGame("White", "lc0", "Black", "Human", "Result", "1-0"), "loss", "lc0"},
+		{taggedGame("White", "lc0", "Black", "Human", "Result", "1/2-1/2"), "draw", "lc0"},
+		{taggedGame("White", "Human", "Black", "stockfish", "Result", "*"), "", "stockfish"},
+		{taggedGame("White", "Ann", "Black", "Bob", "Duel", "true", "Result", "1-0"), "", "Ann vs Bob"},
+		{taggedGame("White", "Ann", "Black", "Bob", "Duel", "true", "Result", "1/2-1/2"), "", "Ann vs Bob"},
+	}
+	for _, test := range tests {
+		if result := humanResult(test.game); result != test.result {
+			t.Errorf("humanResult of %v = %q, want %q", test.game.TagPairs(), result, test.result)
+		}
+		if opponent := gameOpponent(test.game); opponent != test.opponent {
+			t.Errorf("gameOpponent of %v = %q, want %q", test.game.TagPairs(), opponent, test.opponent)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abperiasamy/pinata/uci"
+)
+
+// Search budget chosen on the command-line. Move time and node count
+// take over from the default depth, a --depth given with them still applies.
+func flagSearchLimits() uci.Limits {
+	if gEngineMoveTime > 0 || gEngineNodes > 0 {
+		limits := uci.Limits{
+			MoveTime: time.Duration(gEngineMoveTime) * time.Millisecond,
+			Nodes:    gEngineNodes,
+		}
+		if gRootFlags.Changed("depth") {
+			limits.Depth = gEngineDepth
+		}
+		return limits
+	}
+	return uci.Limits{Depth: gEngineDepth}
+}
+
+// Parse a search budget like "depth 12", "movetime 500" or "nodes 100000 movetime 2000".
+func parseSearchLimits(args []string) (limits uci.Limits, err error) {
+	if len(args) == 0 || len(args)%2 != 0 {
+		return limits, fmt.Errorf("limit must be [depth N] [movetime MS] [nodes N]")
+	}
+	for i := 0; i < len(args); i += 2 {
+		n, err := strconv.Atoi(args[i+1])
+		if err != nil || n < 1 {
+			return limits, fmt.Errorf("invalid limit %q", args[i+1])
+		}
+		switch strings.ToLower(args[i]) {
+		case "depth":
+			limits.Depth = n
+		case "movetime":
+			limits.MoveTime = time.Duration(n) * time.Millisecond
+		case "nodes":
+			limits.Nodes = n
+		default:
+			return limits, fmt.Errorf("unknown limit %q, use depth, movetime or nodes", args[i])
+		}
+	}
+	return limits, nil
+}
+
+// Human readable search budget.
+func limitsString(limits uci.Limits) string {
+	var budget []string
+	if limits.Depth > 0 {
+		budget = append(budget, "depth "+strconv.Itoa(limits.Depth))
+	}
+	if limits.MoveTime > 0 {
+		budget = append(budget, "movetime "+strconv.FormatInt(limits.MoveTime.Milliseconds(), 10)+" ms")
+	}
+	if limits.Nodes > 0 {
+		budget = append(budget, "nodes "+strconv.Itoa(limits.Nodes))
+	}
+	return strings.Join(budget, ", ")
+}
+
+// Print the engine's current search budget.
+func showSearchLimits() {
+	if gClock != nil {
+		fmt.Println("Engine is searching on the", gConsole.Bold(gConsole.Yellow("clock")), "("+gClock.timeControl()+"), hints use", limitsString(gSearchLimits))
+		return
+	}
+	fmt.Println("Engine is searching to", gConsole.Bold(gConsole.Yellow(limitsString(gSearchLimits))))
+}
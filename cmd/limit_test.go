@@ -0,0 +1,83 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abperiasamy/pinata/uci"
+)
+
+func TestParseSearchLimits(t *testing.T) {
+	tests := []struct {
+		args string
+		want uci.Limits
+		text string
+	}{
+		{"depth 12", uci.Limits{Depth: 12}, "depth 12"},
+		{"movetime 500", uci.Limits{MoveTime: 500 * time.Millisecond}, "movetime 500 ms"},
+		{"nodes 100000 movetime 2000", uci.Limits{Nodes: 100000, MoveTime: 2 * time.Second}, "movetime 2000 ms, nodes 100000"},
+		{"Depth 8 NODES 5000", uci.Limits{Depth: 8, Nodes: 5000}, "depth 8, nodes 5000"},
+		{"depth 8 depth 10", uci.Limits{Depth: 10}, "depth 10"},
+	}
+	for _, test := range tests {
+		limits, err := parseSearchLimits(strings.Fields(test.args))
+		if err != nil || limits != test.want {
+			t.Errorf("parseSearchLimits(%q) = %+v, %v, want %+v", test.args, limits, err, test.want)
+		}
+		if text := limitsString(limits); text != test.text {
+			t.Errorf("limitsString of %q = %q, want %q", test.args, text, test.text)
+		}
+	}
+
+	for _, args := range []string{"", "depth", "depth 0", "depth -1", "depth x", "nodes 1e6", "time 500", "depth 5 movetime"} {
+		if _, err := parseSearchLimits(strings.Fields(args)); err == nil {
+			t.Errorf("parseSearchLimits(%q) succeeded, want an error", args)
+		}
+	}
+}
+
+func TestFlagSearchLimits(t *testing.T) {
+	saved := []int{gEngineDepth, gEngineMoveTime, gEngineNodes}
+	depth := rootCmd.PersistentFlags().Lookup("depth")
+	t.Cleanup(func() {
+		gEngineDepth, gEngineMoveTime, gEngineNodes = saved[0], saved[1], saved[2]
+		depth.Changed = false
+	})
+
+	tests := []struct {
+		depth, moveTime, nodes int
+		depthGiven             bool // --depth on the command line.
+		want                   uci.Limits
+	}{
+		{12, 0, 0, false, uci.Limits{Depth: 12}},
+		{12, 500, 0, false, uci.Limits{MoveTime: 500 * time.Millisecond}},
+		{12, 0, 5000, false, uci.Limits{Nodes: 5000}},
+		{12, 500, 0, true, uci.Limits{Depth: 12, MoveTime: 500 * time.Millisecond}},
+		{8, 0, 5000, true, uci.Limits{Depth: 8, Nodes: 5000}},
+	}
+	for _, test := range tests {
+		gEngineDepth, gEngineMoveTime, gEngineNodes = test.depth, test.moveTime, test.nodes
+		depth.Changed = test.depthGiven
+		if got := flagSearchLimits(); got != test.want {
+			t.Errorf("flagSearchLimits with depth %d (given %v), movetime %d and nodes %d = %+v, want %+v",
+				test.depth, test.depthGiven, test.moveTime, test.nodes, got, test.want)
+		}
+	}
+}
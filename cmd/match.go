@@ -0,0 +1,450 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+	"github.com/abperiasamy/pinata/uci"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// SPRT error rates, the chance of accepting the wrong hypothesis.
+const (
+	gSPRTAlpha = 0.05
+	gSPRTBeta  = 0.05
+)
+
+// matchCmd plays two engines against each other
+var matchCmd = &cobra.Command{
+	Use:   "match",
+	Short: "Play a match between two UCI compatible engines.",
+	Long: `The engines alternate colors every game. With --openings, every opening
+of the PGN or EPD file is played twice, once with each engine as white.
+The games are saved to a single PGN file and the final score is reported
+with the Elo difference and its 95% error margin.`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		onStart()
+		if err := playMatch(); err != nil {
+			fmt.Println(gConsole.Bold(gConsole.Red(err)))
+			os.Exit(1)
+		}
+		onStop()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(matchCmd)
+
+	matchCmd.Flags().StringVar(&gMatchEngine1, "engine1", "", "path to the first UCI compatible chess engine executable")
+	matchCmd.Flags().StringVar(&gMatchEngine2, "engine2", "", "path to the second UCI compatible chess engine executable")
+	matchCmd.Flags().IntVarP(&gMatchGames, "games", "n", 2, "number of games to play")
+	matchCmd.Flags().StringVar(&gMatchOpenings, "openings", "", "play the starting positions of a PGN or EPD file")
+	matchCmd.Flags().StringVar(&gMatchPGN, "pgn", "match.pgn", "save the games to this PGN file")
+	matchCmd.Flags().StringVar(&gMatchSPRT, "sprt", "", "stop early once an SPRT between Elo bounds elo0,elo1 (like 0,5) decides")
+	matchCmd.MarkFlagRequired("engine1")
+	matchCmd.MarkFlagRequired("engine2")
+}
+
+// Starting position of a match game, with the opening moves played from it.
+type matchOpening struct {
+	fen   string
+	moves []*chess.Move
+}
+
+// Match score from the first engine's point of view.
+type matchScore struct {
+	wins, draws, losses int
+}
+
+func (s matchScore) games() int {
+	return s.wins + s.draws + s.losses
+}
+
+func (s matchScore) points() float64 {
+	return float64(s.wins) + float64(s.draws)/2
+}
+
+// Swap the point of view to the second engine.
+func (s matchScore) reverse() matchScore {
+	return matchScore{wins: s.losses, draws: s.draws, losses: s.wins}
+}
+
+// Count a "win", "draw" or "loss".
+func (s *matchScore) add(result string) {
+	switch result {
+	case "win":
+		s.wins++
+	case "draw":
+		s.draws++
+	case "loss":
+		s.losses++
+	}
+}
+
+// Result of the first engine, playing white if white is 0, as counted by add.
+func matchResult(outcome chess.Outcome, white int) string {
+	switch {
+	case outcome == chess.Draw:
+		return "draw"
+	case outcome == chess.NoOutcome:
+		return ""
+	case (outcome == chess.WhiteWon) == (white == 0):
+		return "win"
+	}
+	return "loss"
+}
+
+// Mean and variance of a single game's score.
+func (s matchScore) stats() (mean, variance float64) {
+	n := float64(s.games())
+	mean = s.points() / n
+	variance = (float64(s.wins)*math.Pow(1-mean, 2) +
+		float64(s.draws)*math.Pow(0.5-mean, 2) +
+		float64(s.losses)*math.Pow(mean, 2)) / n
+	return mean, variance
+}
+
+// Elo difference and its 95% error margin.
+func (s matchScore) elo() (diff, margin float64) {
+	if s.games() == 0 {
+		return 0, math.NaN()
+	}
+	mean, variance := s.stats()
+	dev := 1.96 * math.Sqrt(variance/float64(s.games()))
+	return scoreToElo(mean), (scoreToElo(mean+dev) - scoreToElo(mean-dev)) / 2
+}
+
+// Log-likelihood ratio of H1 (Elo difference elo1) against H0 (elo0),
+// using the normal approximation of the game scores.
+func (s matchScore) llr(elo0, elo1 float64) float64 {
+	if s.games() == 0 {
+		return 0
+	}
+	mean, variance := s.stats()
+	if variance == 0 {
+		return 0
+	}
+	s0, s1 := eloToScore(elo0), eloToScore(elo1)
+	return (s1 - s0) * (2*mean - s0 - s1) / (2 * variance / float64(s.games()))
+}
+
+// Expected score of the Elo difference.
+func eloToScore(elo float64) float64 {
+	return 1 / (1 + math.Pow(10, -elo/400))
+}
+
+// Elo difference of the expected score. Infinite for a whitewash.
+func scoreToElo(score float64) float64 {
+	return 400 * math.Log10(score/(1-score))
+}
+
+// Format the Elo difference as "+35 ± 20".
+func formatElo(diff, margin float64) string {
+	if math.IsInf(diff, 0) || math.IsInf(margin, 0) || math.IsNaN(margin) {
+		return fmt.Sprintf("%+.0f", diff)
+	}
+	return fmt.Sprintf("%+.0f ± %.0f", diff, margin)
+}
+
+// Parse the SPRT Elo bounds "elo0,elo1".
+func parseSPRT(bounds string) (elo0, elo1 float64, err error) {
+	parts := strings.Split(bounds, ",")
+	if len(parts) == 2 {
+		elo0, err = strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+		if err == nil {
+			elo1, err = strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		}
+		if err == nil && elo1 > elo0 {
+			return elo0, elo1, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("invalid SPRT bounds %q, use elo0,elo1 like 0,5", bounds)
+}
+
+// Load the starting positions of a PGN file, or of an EPD file by extension.
+func loadOpenings(filename string) ([]matchOpening, error) {
+	dat, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var openings []matchOpening
+	if strings.EqualFold(filepath.Ext(filename), ".epd") {
+		for i, line := range strings.Split(string(dat), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+				continue
+			}
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("%s:%d: invalid EPD position", filename, i+1)
+			}
+			fen := strings.Join(fields[:4], " ") + " 0 1" // EPD has no move counters.
+			if _, err := chess.FEN(fen); err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", filename, i+1, err)
+			}
+			openings = append(openings, matchOpening{fen: fen})
+		}
+	} else {
+		games, err := parsePGN(string(dat))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+		for i, g := range games {
+			game, _, err := g.game()
+			if err != nil {
+				return nil, fmt.Errorf("%s: game %d: %v", filename, i+1, err)
+			}
+			openings = append(openings, matchOpening{fen: game.Positions()[0].String(), moves: game.Moves()})
+		}
+	}
+
+	if len(openings) == 0 {
+		return nil, fmt.Errorf("%s has no starting positions", filename)
+	}
+	return openings, nil
+}
+
+// Engine names for the score table, told apart when both are the same.
+func matchPlayerNames(engines [2]*uci.Engine, paths [2]string) (names [2]string) {
+	for i, eng := range engines {
+		names[i] = eng.Name
+		if names[i] == "" {
+			names[i] = filepath.Base(paths[i])
+		}
+	}
+	if names[0] == names[1] {
+		names[0] += " #1"
+		names[1] += " #2"
+	}
+	return names
+}
+
+// Play the match and print the final score table.
+func playMatch() error {
+	if gMatchGames < 1 {
+		return fmt.Errorf("invalid number of games %d", gMatchGames)
+	}
+	var elo0, elo1 float64
+	if gMatchSPRT != "" {
+		var err error
+		if elo0, elo1, err = parseSPRT(gMatchSPRT); err != nil {
+			return err
+		}
+	}
+	if gTimeControl != "" {
+		if _, err := newChessClock(gTimeControl); err != nil {
+			return err
+		}
+	}
+	startFEN := chess.NewGame().FEN()
+	openings := []matchOpening{{fen: startFEN}}
+	if gMatchOpenings != "" {
+		var err error
+		if openings, err = loadOpenings(gMatchOpenings); err != nil {
+			return err
+		}
+	}
+
+	paths := [2]string{gMatchEngine1, gMatchEngine2}
+	var engines [2]*uci.Engine
+	for i, path := range paths {
+		eng, err := newEngine(path)
+		if err != nil {
+			return err
+		}
+		defer eng.Close()
+		engines[i] = eng
+	}
+	names := matchPlayerNames(engines, paths)
+
+	file, err := os.Create(gMatchPGN)
+	if err != nil {
+		return fmt.Errorf("unable to create %s", gMatchPGN)
+	}
+	defer file.Close()
+
+	var score matchScore
+	lower, upper := math.Log(gSPRTBeta/(1-gSPRTAlpha)), math.Log((1-gSPRTBeta)/gSPRTAlpha)
+	sprt := ""
+	for round := 1; round <= gMatchGames; round++ {
+		white := (round - 1) % 2 // Engines swap colors every game, and every opening is played by both.
+		opening := openings[(round-1)/2%len(openings)]
+
+		game, comments, termination, err := playMatchGame(engines, white, opening)
+		if game == nil {
+			return err
+		}
+		game.AddTagPair("Event", gMatchEvent)
+		game.AddTagPair("Round", strconv.Itoa(round))
+		game.AddTagPair("White", names[white])
+		game.AddTagPair("Black", names[1-white])
+		addGameTags(game)
+		if opening.fen != startFEN {
+			game.AddTagPair("SetUp", "1")
+			game.AddTagPair("FEN", opening.fen)
+		}
+		if termination != "" {
+			game.AddTagPair("Termination", termination)
+		}
+		if gTimeControl != "" {
+			clock, _ := newChessClock(gTimeControl)
+			game.AddTagPair("TimeControl", clock.timeControl())
+		}
+		if _, err := file.WriteString(encodePGN(game, comments) + "\n"); err != nil {
+			return fmt.Errorf("unable to save the game to %s", gMatchPGN)
+		}
+
+		method := termination
+		if method == "" {
+			method = game.Method().String()
+		}
+		fmt.Printf("Game %d of %d: %s - %s %s (%s)\n", round, gMatchGames,
+			names[white], names[1-white], gConsole.Bold(gConsole.Yellow(game.Outcome())), method)
+		if err != nil {
+			return err // Engine failure, the match can not go on.
+		}
+
+		score.add(matchResult(game.Outcome(), white))
+
+		if gMatchSPRT != "" {
+			if llr := score.llr(elo0, elo1); llr >= upper {
+				sprt = fmt.Sprintf("H1 (%+g Elo) accepted, LLR %.2f", elo1, llr)
+			} else if llr <= lower {
+				sprt = fmt.Sprintf("H0 (%+g Elo) accepted, LLR %.2f", elo0, llr)
+			}
+			if sprt != "" {
+				break
+			}
+		}
+	}
+
+	printMatchScore(names, score)
+	if gMatchSPRT != "" {
+		if sprt == "" {
+			sprt = fmt.Sprintf("inconclusive, LLR %.2f [%.2f, %.2f]", score.llr(elo0, elo1), lower, upper)
+		}
+		fmt.Println("SPRT:", gConsole.Bold(sprt))
+	}
+	fmt.Println("Games saved to", gConsole.Bold(gMatchPGN))
+	return nil
+}
+
+// Play one match game from the opening, engines[white] playing white.
+// Returns the game with its clock comments and any termination other than
+// a normal end. The error reports an engine failure.
+func playMatchGame(engines [2]*uci.Engine, white int, opening matchOpening) (*chess.Game, []string, string, error) {
+	fen, err := chess.FEN(opening.fen)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	game := chess.NewGame(fen, chess.UseNotation(chess.AlgebraicNotation{}))
+	var moves []string
+	comments := make([]string, len(opening.moves))
+	for _, move := range opening.moves {
+		if err := game.Move(move); err != nil {
+			return nil, nil, "", err
+		}
+		moves = append(moves, move.String())
+	}
+
+	var clock *chessClock
+	if gTimeControl != "" {
+		clock, _ = newChessClock(gTimeControl)
+	}
+	for _, eng := range engines {
+		eng.NewGame()
+	}
+
+	for game.Outcome() == chess.NoOutcome {
+		// Claim draws on behalf of the engines.
+		for _, method := range game.EligibleDraws() {
+			if method == chess.ThreefoldRepetition || method == chess.FiftyMoveRule {
+				game.Draw(method)
+				break
+			}
+		}
+		if game.Outcome() != chess.NoOutcome {
+			break
+		}
+
+		turn := game.Position().Turn()
+		eng := engines[white]
+		if turn == chess.Black {
+			eng = engines[1-white]
+		}
+
+		limits := gSearchLimits
+		if clock != nil {
+			clock.start(turn)
+			limits = clock.limits()
+		}
+		eng.SetPosition(opening.fen, moves...)
+		results, err := eng.Go(limits, uci.HighestDepthOnly)
+		if err != nil {
+			game.Resign(turn)
+			return game, comments, "abandoned", err
+		}
+		if clock != nil && !clock.stop() {
+			game.Resign(turn)
+			return game, comments, "time forfeit", nil
+		}
+
+		move, err := chess.LongAlgebraicNotation{}.Decode(game.Position(), results.BestMove)
+		if err == nil {
+			err = game.Move(move)
+		}
+		if err != nil { // Illegal move.
+			game.Resign(turn)
+			return game, comments, "rules infraction", nil
+		}
+		moves = append(moves, results.BestMove)
+		if clock != nil {
+			comments = append(comments, "[%clk "+formatClk(clock.remaining[turn])+"]")
+		}
+	}
+	return game, comments, "", nil
+}
+
+// Print the match score of both engines.
+func printMatchScore(names [2]string, score matchScore) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Engine", "Games", "Wins", "Draws", "Losses", "Score", "Elo"})
+	table.SetAlignment(tablewriter.ALIGN_RIGHT)
+	for i, s := range []matchScore{score, score.reverse()} {
+		diff, margin := s.elo()
+		table.Append([]string{
+			names[i],
+			strconv.Itoa(s.games()),
+			strconv.Itoa(s.wins),
+			strconv.Itoa(s.draws),
+			strconv.Itoa(s.losses),
+			strconv.FormatFloat(s.points(), 'f', -1, 64),
+			formatElo(diff, margin),
+		})
+	}
+	table.Render()
+}
@@ -0,0 +1,179 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abperiasamy/chess"
+)
+
+// Whether the floats agree to the tolerance.
+func near(got, want, tolerance float64) bool {
+	return math.Abs(got-want) <= tolerance
+}
+
+func TestMatchScore(t *testing.T) {
+	var score matchScore
+	for _, result := range []string{"win", "win", "draw", "loss", "aborted"} {
+		score.add(result)
+	}
+	if score != (matchScore{wins: 2, draws: 1, losses: 1}) {
+		t.Errorf("score = %+v, want 2 wins, 1 draw and 1 loss", score)
+	}
+	if score.games() != 4 || score.points() != 2.5 {
+		t.Errorf("score has %d games and %v points, want 4 and 2.5", score.games(), score.points())
+	}
+	if reversed := score.reverse(); reversed != (matchScore{wins: 1, draws: 1, losses: 2}) || reversed.points() != 1.5 {
+		t.Errorf("reverse = %+v, want 1 win, 1 draw and 2 losses", reversed)
+	}
+}
+
+func TestMatchResult(t *testing.T) {
+	tests := []struct {
+		outcome chess.Outcome
+		white   int
+		want    string
+	}{
+		{chess.WhiteWon, 0, "win"},
+		{chess.WhiteWon, 1, "loss"},
+		{chess.BlackWon, 0, "loss"},
+		{chess.BlackWon, 1, "win"},
+		{chess.Draw, 1, "draw"},
+		{chess.NoOutcome, 0, ""},
+	}
+	for _, test := range tests {
+		if got := matchResult(test.outcome, test.white); got != test.want {
+			t.Errorf("matchResult(%s, %d) = %q, want %q", test.outcome, test.white, got, test.want)
+		}
+	}
+}
+
+func TestPlayMatchEngineError(t *testing.T) {
+	t.Setenv("PINATA_FAKE_ENGINE", "1")
+	defer func(engine1, engine2 string, games int) {
+		gMatchEngine1, gMatchEngine2, gMatchGames = engine1, engine2, games
+	}(gMatchEngine1, gMatchEngine2, gMatchGames)
+
+	// The second engine failing to start ends the match with an error, not an exit.
+	gMatchEngine1, gMatchEngine2, gMatchGames = os.Args[0], filepath.Join(t.TempDir(), "no-engine"), 2
+	if err := playMatch(); err == nil || !strings.Contains(err.Error(), "unable to find") {
+		t.Errorf("playMatch with a missing engine failed with %v", err)
+	}
+}
+
+func TestEloScore(t *testing.T) {
+	tests := []struct {
+		elo, score float64
+	}{
+		{0, 0.5},
+		{100, 0.64006},
+		{-100, 0.35994},
+		{190.8485, 0.75},
+		{400, 10.0 / 11},
+	}
+	for _, test := range tests {
+		if got := eloToScore(test.elo); !near(got, test.score, 1e-5) {
+			t.Errorf("eloToScore(%v) = %v, want %v", test.elo, got, test.score)
+		}
+		if got := scoreToElo(test.score); !near(got, test.elo, 1e-2) {
+			t.Errorf("scoreToElo(%v) = %v, want %v", test.score, got, test.elo)
+		}
+	}
+	if !math.IsInf(scoreToElo(1), 1) || !math.IsInf(scoreToElo(0), -1) {
+		t.Error("a whitewash is not an infinite Elo difference")
+	}
+}
+
+func TestMatchElo(t *testing.T) {
+	tests := []struct {
+		score        matchScore
+		diff, margin float64
+		llr          float64 // For SPRT bounds 0,5.
+	}{
+		{matchScore{wins: 10, losses: 10}, 0, 163.32, -0.0021},
+		{matchScore{wins: 30, draws: 40, losses: 30}, 0, 53.16, -0.0173},
+		{matchScore{wins: 60, draws: 20, losses: 20}, 147.19, 66.01, 0.8832},
+		{matchScore{wins: 12, draws: 30, losses: 8}, 27.85, 61.45, 0.1331},
+	}
+	for _, test := range tests {
+		diff, margin := test.score.elo()
+		if !near(diff, test.diff, 0.01) || !near(margin, test.margin, 0.01) {
+			t.Errorf("elo of %+v = %.2f ± %.2f, want %.2f ± %.2f", test.score, diff, margin, test.diff, test.margin)
+		}
+		if llr := test.score.llr(0, 5); !near(llr, test.llr, 1e-4) {
+			t.Errorf("llr of %+v = %.4f, want %.4f", test.score, llr, test.llr)
+		}
+		if reversed, _ := test.score.reverse().elo(); !near(reversed, -test.diff, 0.01) {
+			t.Errorf("elo of %+v reversed = %.2f, want %.2f", test.score, reversed, -test.diff)
+		}
+	}
+
+	if diff, margin := (matchScore{}).elo(); diff != 0 || !math.IsNaN(margin) {
+		t.Errorf("elo without games = %v ± %v, want 0 ± NaN", diff, margin)
+	}
+	for _, score := range []matchScore{{}, {draws: 5}, {wins: 3}} {
+		if llr := score.llr(0, 5); llr != 0 {
+			t.Errorf("llr of %+v = %v, want 0 without variance", score, llr)
+		}
+	}
+}
+
+func TestFormatElo(t *testing.T) {
+	tests := []struct {
+		diff, margin float64
+		want         string
+	}{
+		{35.4, 20.2, "+35 ± 20"},
+		{-12.6, 40, "-13 ± 40"},
+		{0, math.NaN(), "+0"},
+		{math.Inf(1), math.NaN(), "+Inf"},
+		{150, math.Inf(1), "+150"},
+	}
+	for _, test := range tests {
+		if got := formatElo(test.diff, test.margin); got != test.want {
+			t.Errorf("formatElo(%v, %v) = %q, want %q", test.diff, test.margin, got, test.want)
+		}
+	}
+}
+
+func TestParseSPRT(t *testing.T) {
+	tests := []struct {
+		bounds     string
+		elo0, elo1 float64
+	}{
+		{"0,5", 0, 5},
+		{" -2.5 , 2.5 ", -2.5, 2.5},
+		{"5,10", 5, 10},
+	}
+	for _, test := range tests {
+		elo0, elo1, err := parseSPRT(test.bounds)
+		if err != nil || elo0 != test.elo0 || elo1 != test.elo1 {
+			t.Errorf("parseSPRT(%q) = %v, %v, %v, want %v, %v", test.bounds, elo0, elo1, err, test.elo0, test.elo1)
+		}
+	}
+
+	for _, bounds := range []string{"", "5", "5,5", "5,0", "0,5,10", "a,b", "0;5"} {
+		if _, _, err := parseSPRT(bounds); err == nil {
+			t.Errorf("parseSPRT(%q) succeeded, want an error", bounds)
+		}
+	}
+}
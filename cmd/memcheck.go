@@ -0,0 +1,184 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+	"github.com/chzyer/readline"
+)
+
+// Piece of a FEN letter, upper case for white.
+func pieceOfLetter(letter byte) (chess.Piece, bool) {
+	pieceType, ok := parsePieceType(strings.ToLower(string(letter)))
+	if !ok {
+		return chess.NoPiece, false
+	}
+	color := chess.Black
+	if letter >= 'A' && letter <= 'Z' {
+		color = chess.White
+	}
+	return coloredPiece(color, pieceType), true
+}
+
+// Name of the piece in words like "White Knight".
+func pieceName(piece chess.Piece) string {
+	return piece.Color().Name() + " " + pieceTypeName(piece.Type(), 1)
+}
+
+// Parse the piece placement of a FEN, the rest of the FEN is ignored.
+func parseFENBoard(fen string) (map[chess.Square]chess.Piece, error) {
+	ranks := strings.Split(strings.Fields(fen)[0], "/")
+	if len(ranks) != 8 {
+		return nil, fmt.Errorf("a FEN board needs 8 ranks, not %d", len(ranks))
+	}
+	board := make(map[chess.Square]chess.Piece)
+	for i, rank := range ranks {
+		file := 0
+		for j := 0; j < len(rank); j++ {
+			if rank[j] >= '1' && rank[j] <= '8' {
+				file += int(rank[j] - '0')
+				continue
+			}
+			piece, ok := pieceOfLetter(rank[j])
+			if !ok || file > 7 {
+				return nil, fmt.Errorf("invalid rank %q", rank)
+			}
+			board[chess.Square(8*(7-i)+file)] = piece
+			file++
+		}
+		if file != 8 {
+			return nil, fmt.Errorf("rank %q does not have 8 squares", rank)
+		}
+	}
+	return board, nil
+}
+
+// Parse a piece list like "Ke1 Qd1 e4 / ke8 e5". Pieces before the slash are
+// white and after it black, where a bare square is a pawn. Without a slash
+// the letter case tells the color, upper case for white.
+func parsePieceList(list string) (map[chess.Square]chess.Piece, error) {
+	sides := strings.Split(list, "/")
+	if len(sides) > 2 {
+		return nil, fmt.Errorf("separate white and black pieces with a single /")
+	}
+	board := make(map[chess.Square]chess.Piece)
+	for i, side := range sides {
+		for _, token := range strings.FieldsFunc(side, func(r rune) bool { return r == ' ' || r == ',' || r == ';' }) {
+			if len(token) < 2 || len(token) > 3 {
+				return nil, fmt.Errorf("invalid piece %q", token)
+			}
+			var piece chess.Piece
+			sq := strToSquare(strings.ToLower(token[len(token)-2:]))
+			switch {
+			case sq == chess.NoSquare:
+				return nil, fmt.Errorf("invalid piece %q", token)
+			case len(token) == 2 && len(sides) == 1:
+				return nil, fmt.Errorf("pawn %q needs a color, use P%s or p%s", token, token, token)
+			case len(token) == 2:
+				piece = coloredPiece([]chess.Color{chess.White, chess.Black}[i], chess.Pawn)
+			default:
+				p, ok := pieceOfLetter(token[0])
+				if !ok {
+					return nil, fmt.Errorf("invalid piece %q", token)
+				}
+				piece = p
+				if len(sides) == 2 { // The side tells the color.
+					piece = coloredPiece([]chess.Color{chess.White, chess.Black}[i], p.Type())
+				}
+			}
+			board[sq] = piece
+		}
+	}
+	return board, nil
+}
+
+// Parse the position typed by the player, as a FEN or as a piece list.
+func parseRecalledBoard(input string) (map[chess.Square]chess.Piece, error) {
+	if first := strings.Fields(input)[0]; strings.Count(first, "/") == 7 {
+		return parseFENBoard(input)
+	}
+	return parsePieceList(input)
+}
+
+// Compare the recalled board against the actual one. Accuracy is the share
+// of correct squares among all squares occupied on either board.
+func compareBoards(actual, recalled map[chess.Square]chess.Piece) (accuracy int, missed, wrong []string) {
+	correct, occupied := 0, 0
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		a, r := actual[sq], recalled[sq]
+		if a == chess.NoPiece && r == chess.NoPiece {
+			continue
+		}
+		occupied++
+		switch {
+		case a == r:
+			correct++
+		case r == chess.NoPiece:
+			missed = append(missed, pieceName(a)+" "+sq.String())
+		case a == chess.NoPiece:
+			wrong = append(wrong, pieceName(r)+" "+sq.String()+" (empty)")
+		default:
+			wrong = append(wrong, pieceName(r)+" "+sq.String()+" (is "+pieceName(a)+")")
+		}
+	}
+	if occupied > 0 {
+		accuracy = 100 * correct / occupied
+	}
+	return accuracy, missed, wrong
+}
+
+// Ask the player to type the position from memory and score the answer.
+func memCheck(l *readline.Instance, game *chess.Game) {
+	gMemCheckMove = fullMoveNumber(game.Position())
+	fmt.Println("Memory check: type the position as a FEN or a piece list like",
+		gConsole.Bold(gConsole.Yellow("Ke1 Qd1 e4 / ke8 e5")).String()+", or nothing to skip.")
+	for {
+		l.SetPrompt("Position: ")
+		input, err := l.Readline()
+		if err != nil || strings.TrimSpace(input) == "" {
+			fmt.Println("Memory check skipped.")
+			return
+		}
+
+		recalled, err := parseRecalledBoard(input)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+
+		accuracy, missed, wrong := compareBoards(game.Position().Board().SquareMap(), recalled)
+		fmt.Println("Accuracy:", gConsole.Bold(gConsole.Yellow(strconv.Itoa(accuracy)+"%")))
+		if len(missed) > 0 {
+			fmt.Println("Missed:", strings.Join(missed, "; "))
+		}
+		if len(wrong) > 0 {
+			fmt.Println("Wrong:", strings.Join(wrong, "; "))
+		}
+		gMemChecks = append(gMemChecks, strconv.Itoa(fullMoveNumber(game.Position()))+":"+strconv.Itoa(accuracy))
+		return
+	}
+}
+
+// Whether --memcheck-every asks for a memory check at this move.
+func memCheckDue(game *chess.Game) bool {
+	moveNum := fullMoveNumber(game.Position())
+	return gMemCheckEvery > 0 && len(game.Moves()) > 0 && moveNum%gMemCheckEvery == 0 && moveNum != gMemCheckMove
+}
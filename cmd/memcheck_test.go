@@ -0,0 +1,116 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/abperiasamy/chess"
+)
+
+func TestParseFENBoard(t *testing.T) {
+	board, err := parseFENBoard("4k3/8/8/8/8/8/4P3/4K3 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[chess.Square]chess.Piece{chess.E8: chess.BlackKing, chess.E2: chess.WhitePawn, chess.E1: chess.WhiteKing}
+	if !reflect.DeepEqual(board, want) {
+		t.Errorf("parseFENBoard = %v, want %v", board, want)
+	}
+
+	start, err := parseFENBoard(testStartFEN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := chess.NewGame().Position().Board().SquareMap(); !reflect.DeepEqual(start, actual) {
+		t.Errorf("parseFENBoard of the start position = %v, want %v", start, actual)
+	}
+
+	for _, fen := range []string{"8/8/8/8/8/8/8", "8/8/8/8/8/8/8/7", "8/8/8/8/8/8/8/9", "8/8/8/8/8/8/8/4X3", "8/8/8/8/8/8/8/44k", "8/8/8/8/8/8/8/kkkkkkkkk"} {
+		if _, err := parseFENBoard(fen); err == nil {
+			t.Errorf("parseFENBoard(%q) succeeded, want an error", fen)
+		}
+	}
+}
+
+func TestParseRecalledBoard(t *testing.T) {
+	kings := map[chess.Square]chess.Piece{chess.E1: chess.WhiteKing, chess.E8: chess.BlackKing}
+	pawns := map[chess.Square]chess.Piece{chess.E1: chess.WhiteKing, chess.E4: chess.WhitePawn, chess.E8: chess.BlackKing, chess.E5: chess.BlackPawn}
+	tests := []struct {
+		input string
+		want  map[chess.Square]chess.Piece
+	}{
+		{"4k3/8/8/8/8/8/8/4K3", kings},
+		{"4k3/8/8/8/8/8/8/4K3 w - - 0 1", kings},
+		{"Ke1 ke8", kings},
+		{"Ke1, ke8", kings},
+		{"Ke1 e4 / Ke8 e5", pawns},
+		{"ke1 E4 / KE8 e5", pawns}, // The side tells the color, not the case.
+		{"Ke1 Pe4 ke8 pe5", pawns},
+		{"Ke1 PE4; ke8 pe5", pawns},
+		{"Ke1 e4 /", map[chess.Square]chess.Piece{chess.E1: chess.WhiteKing, chess.E4: chess.WhitePawn}},
+	}
+	for _, test := range tests {
+		got, err := parseRecalledBoard(test.input)
+		if err != nil {
+			t.Errorf("parseRecalledBoard(%q) failed: %v", test.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseRecalledBoard(%q) = %v, want %v", test.input, got, test.want)
+		}
+	}
+
+	for _, input := range []string{"Ke1 e4", "Ke1 / ke8 / e5", "Xe1", "Ke9", "K", "Kne1", "4k3/8/8/8/8/8/8"} {
+		if _, err := parseRecalledBoard(input); err == nil {
+			t.Errorf("parseRecalledBoard(%q) succeeded, want an error", input)
+		}
+	}
+}
+
+func TestCompareBoards(t *testing.T) {
+	actual := map[chess.Square]chess.Piece{chess.E1: chess.WhiteKing, chess.E4: chess.WhitePawn, chess.E8: chess.BlackKing, chess.D8: chess.BlackQueen}
+	tests := []struct {
+		recalled      string
+		accuracy      int
+		missed, wrong []string
+	}{
+		{"Ke1 e4 / ke8 qd8", 100, nil, nil},
+		{"Ke1 / ke8 qd8", 75, []string{"White Pawn e4"}, nil},
+		{"Ke1 e4 / ke8 qd8 a7", 80, nil, []string{"Black Pawn a7 (empty)"}},
+		{"Ke1 e4 / ke8 rd8", 75, nil, []string{"Black Rook d8 (is Black Queen)"}},
+		{"Kd1 e4 / ke8 qd8", 60, []string{"White King e1"}, []string{"White King d1 (empty)"}},
+		{"Ka1 / ka8", 0, []string{"White King e1", "White Pawn e4", "Black Queen d8", "Black King e8"},
+			[]string{"White King a1 (empty)", "Black King a8 (empty)"}},
+	}
+	for _, test := range tests {
+		recalled, err := parseRecalledBoard(test.recalled)
+		if err != nil {
+			t.Fatalf("parseRecalledBoard(%q) failed: %v", test.recalled, err)
+		}
+		accuracy, missed, wrong := compareBoards(actual, recalled)
+		if accuracy != test.accuracy || !reflect.DeepEqual(missed, test.missed) || !reflect.DeepEqual(wrong, test.wrong) {
+			t.Errorf("compareBoards with %q = %d%% missed %q wrong %q, want %d%% missed %q wrong %q", test.recalled,
+				accuracy, missed, wrong, test.accuracy, test.missed, test.wrong)
+		}
+	}
+
+	if accuracy, _, _ := compareBoards(nil, nil); accuracy != 0 {
+		t.Errorf("compareBoards of empty boards = %d%%, want 0%%", accuracy)
+	}
+}
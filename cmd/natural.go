@@ -0,0 +1,208 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+)
+
+var errNoLegalMove = errors.New("no legal move matches")
+
+// Words carrying no meaning for the move, like "to" in "knight to f3".
+var gFillerWords = map[string]bool{
+	"to": true, "on": true, "at": true, "the": true, "from": true, "square": true,
+	"move": true, "moves": true, "goes": true, "go": true, "plays": true, "play": true,
+	"check": true, "checkmate": true, "mate": true, "and": true,
+}
+
+var gCaptureWords = map[string]bool{"takes": true, "take": true, "captures": true, "capture": true, "x": true}
+
+var gPromoteWords = map[string]bool{"promotes": true, "promote": true, "promoting": true, "promotion": true, "equals": true}
+
+var gCastleWords = map[string]bool{"castle": true, "castles": true, "castling": true}
+
+// A move described in words.
+type naturalMove struct {
+	pieceType chess.PieceType // NoPieceType if not said.
+	from, to  chess.Square    // NoSquare if not said.
+	fromFile  int             // Disambiguation, -1 if not said.
+	fromRank  int
+	capture   bool
+	promo     chess.PieceType
+	castle    chess.MoveTag // 0 if not castling, KingSideCastle|QueenSideCastle for either side.
+}
+
+// Whether the input is worded rather than plain SAN.
+func isNaturalMove(input string) bool {
+	fields := strings.Fields(strings.ToLower(input))
+	return len(fields) > 1 || (len(fields) == 1 && gCastleWords[fields[0]])
+}
+
+// Rank of a digit or a spoken number like "three", -1 if none.
+func parseRankWord(word string) int {
+	if len(word) == 1 && word[0] >= '1' && word[0] <= '8' {
+		return int(word[0] - '1')
+	}
+	for i, w := range gSpokenRanks {
+		if word == w {
+			return i
+		}
+	}
+	return -1
+}
+
+// Parse worded input like "knight takes f three" or "e2 to e4".
+func parseNaturalMove(input string) (*naturalMove, error) {
+	input = strings.ToLower(input)
+	input = strings.NewReplacer(",", " ", ".", " ", "!", " ", "?", " ", "-", " ").Replace(input)
+	words := strings.Fields(input)
+
+	nm := &naturalMove{from: chess.NoSquare, to: chess.NoSquare, fromFile: -1, fromRank: -1}
+	var squares []chess.Square
+	promoting := false
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		switch {
+		case gFillerWords[word]:
+		case gCaptureWords[word]:
+			nm.capture = true
+		case gPromoteWords[word]:
+			promoting = true
+		case gCastleWords[word]:
+			nm.castle = chess.KingSideCastle | chess.QueenSideCastle
+		case word == "kingside" || word == "short" || (word == "king" && i+1 < len(words) && words[i+1] == "side"):
+			nm.castle = chess.KingSideCastle
+			if word == "king" {
+				i++
+			}
+		case word == "queenside" || word == "long" || (word == "queen" && i+1 < len(words) && words[i+1] == "side"):
+			nm.castle = chess.QueenSideCastle
+			if word == "queen" {
+				i++
+			}
+		case strToSquare(word) != chess.NoSquare:
+			squares = append(squares, strToSquare(word))
+		case len(word) == 1 && word[0] >= 'a' && word[0] <= 'h':
+			if i+1 < len(words) && parseRankWord(words[i+1]) >= 0 { // Spoken square like "f three".
+				squares = append(squares, chess.Square(8*parseRankWord(words[i+1])+int(word[0]-'a')))
+				i++
+			} else if word != "a" { // Otherwise "a" is the article, as in "a knight takes".
+				nm.fromFile = int(word[0] - 'a')
+			}
+		case parseRankWord(word) >= 0:
+			nm.fromRank = parseRankWord(word)
+		default:
+			pieceType, ok := parsePieceType(word)
+			if !ok {
+				return nil, fmt.Errorf("unknown word %q in move %q", word, strings.Join(words, " "))
+			}
+			if promoting {
+				nm.promo = pieceType
+			} else if nm.pieceType == chess.NoPieceType {
+				nm.pieceType = pieceType
+			} else { // "pawn e8 queen" promotes without saying so.
+				nm.promo = pieceType
+			}
+		}
+	}
+
+	switch len(squares) {
+	case 0:
+	case 1:
+		nm.to = squares[0]
+	case 2:
+		nm.from, nm.to = squares[0], squares[1]
+	default:
+		return nil, fmt.Errorf("too many squares in move %q", strings.Join(words, " "))
+	}
+	if nm.castle == 0 && nm.to == chess.NoSquare {
+		return nil, fmt.Errorf("which square? say the destination like %q", "knight to f3")
+	}
+	return nm, nil
+}
+
+// Whether the valid move fits the words.
+func (nm *naturalMove) matches(pos *chess.Position, m *chess.Move) bool {
+	if nm.castle != 0 {
+		return m.HasTag(nm.castle&chess.KingSideCastle) || m.HasTag(nm.castle&chess.QueenSideCastle)
+	}
+	piece := pos.Board().Piece(m.S1())
+	switch {
+	case m.S2() != nm.to:
+		return false
+	case nm.from != chess.NoSquare && m.S1() != nm.from:
+		return false
+	case nm.pieceType != chess.NoPieceType && piece.Type() != nm.pieceType:
+		return false
+	case nm.fromFile >= 0 && int(m.S1().File()) != nm.fromFile:
+		return false
+	case nm.fromRank >= 0 && int(m.S1().Rank()) != nm.fromRank:
+		return false
+	case nm.capture && !m.HasTag(chess.Capture) && !m.HasTag(chess.EnPassant):
+		return false
+	case nm.promo != chess.NoPieceType && m.Promo() != nm.promo:
+		return false
+	}
+	return true
+}
+
+// Resolve worded input against the valid moves of the position.
+func resolveNaturalMove(pos *chess.Position, input string) (*chess.Move, error) {
+	nm, err := parseNaturalMove(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []*chess.Move
+	for _, m := range pos.ValidMoves() {
+		if nm.matches(pos, m) {
+			candidates = append(candidates, m)
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("%w %q", errNoLegalMove, input)
+	case 1:
+		return candidates[0], nil
+	}
+
+	// Ask which one, naming the piece if all candidates are the same kind.
+	var texts []string
+	pieceType := pos.Board().Piece(candidates[0].S1()).Type()
+	promoting := false
+	for _, m := range candidates {
+		texts = append(texts, formatMove(pos, m))
+		if pos.Board().Piece(m.S1()).Type() != pieceType {
+			pieceType = chess.NoPieceType
+		}
+		promoting = promoting || m.Promo() != chess.NoPieceType
+	}
+	question := "Which move?"
+	if promoting && pieceType == chess.Pawn && candidates[0].S1() == candidates[len(candidates)-1].S1() {
+		question = "Promote to which piece?"
+	} else if nm.castle != 0 && len(candidates) == 2 {
+		question = "Castle which side?"
+	} else if pieceType != chess.NoPieceType {
+		question = "Which " + strings.ToLower(pieceTypeName(pieceType, 1)) + "?"
+	}
+	return nil, fmt.Errorf("%s %s", question, strings.Join(texts, " or "))
+}
@@ -0,0 +1,87 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"testing"
+
+	"github.com/abperiasamy/chess"
+)
+
+const (
+	testCastlingFEN = "r3k2r/pppq1ppp/2n2n2/3pp3/3PP3/2N2N2/PPPQ1PPP/R3K2R w KQkq - 0 1"
+	testRooksFEN    = "4k3/8/8/8/8/8/4K3/R6R w - - 0 1"
+	testPromoteFEN  = "1n2k3/P7/8/8/8/8/8/4K3 w - - 0 1"
+)
+
+func TestResolveNaturalMove(t *testing.T) {
+	tests := []struct {
+		fen, input, want string
+	}{
+		{testStartFEN, "knight to f3", "Nf3"},
+		{testStartFEN, "knight f three", "Nf3"},
+		{testStartFEN, "e2 to e4", "e4"},
+		{testStartFEN, "pawn to e four", "e4"},
+		{testStartFEN, "a knight to c3", "Nc3"},
+		{testStartFEN, "move a pawn to a4", "a4"},
+		{testStartFEN, "pawn to a four", "a4"},
+		{testCastlingFEN, "castle kingside", "O-O"},
+		{testCastlingFEN, "castles long", "O-O-O"},
+		{testCastlingFEN, "knight takes e5", "Nxe5"},
+		{testCastlingFEN, "d takes e5", "dxe5"},
+		{testRooksFEN, "h rook to d1", "Rhd1"},
+		{testRooksFEN, "rook on a1 to d1", "Rad1"},
+		{testRooksFEN, "a rook to a4", "Ra4"},
+		{testPromoteFEN, "a8 promotes to a queen", "a8=Q"},
+		{testPromoteFEN, "pawn takes b8 knight", "axb8=N"},
+	}
+	for _, test := range tests {
+		fen, err := chess.FEN(test.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pos := chess.NewGame(fen).Position()
+		m, err := resolveNaturalMove(pos, test.input)
+		if err != nil {
+			t.Errorf("resolveNaturalMove(%q) failed: %v", test.input, err)
+			continue
+		}
+		if got := chess.Encoder.Encode(chess.AlgebraicNotation{}, pos, m); got != test.want {
+			t.Errorf("resolveNaturalMove(%q) = %s, want %s", test.input, got, test.want)
+		}
+	}
+}
+
+func TestParseNaturalMoveErrors(t *testing.T) {
+	tests := []string{
+		"knight",
+		"knight to the moon",
+		"e2 e4 e5",
+	}
+	for _, input := range tests {
+		if _, err := parseNaturalMove(input); err == nil {
+			t.Errorf("parseNaturalMove(%q) succeeded, want an error", input)
+		}
+	}
+}
+
+func TestResolveNaturalMoveAmbiguous(t *testing.T) {
+	fen, _ := chess.FEN(testRooksFEN)
+	if _, err := resolveNaturalMove(chess.NewGame(fen).Position(), "rook to d1"); err == nil {
+		t.Error("resolveNaturalMove(\"rook to d1\") succeeded with two rooks reaching d1")
+	}
+}
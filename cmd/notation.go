@@ -0,0 +1,253 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+)
+
+// Notations of the --notation flag and the /notation command, in the order they are listed.
+var gNotations = []string{"san", "lan", "figurine", "descriptive", "de", "fr", "es", "it", "nl", "pt"}
+
+// Localized piece letters for the king, queen, rook, bishop and knight.
+var gPieceLetters = map[string][]string{
+	"figurine": {"♔", "♕", "♖", "♗", "♘"},
+	"de":       {"K", "D", "T", "L", "S"},
+	"fr":       {"R", "D", "T", "F", "C"},
+	"es":       {"R", "D", "T", "A", "C"},
+	"it":       {"R", "D", "T", "A", "C"},
+	"nl":       {"K", "D", "T", "L", "P"},
+	"pt":       {"R", "D", "T", "B", "C"},
+}
+
+// Files of descriptive notation, named after the pieces standing on them at the start.
+var gDescriptiveFiles = []string{"QR", "QN", "QB", "Q", "K", "KB", "KN", "KR"}
+
+// Parse a notation name, "uci" being another name for long algebraic.
+func parseNotation(name string) (string, error) {
+	name = strings.ToLower(name)
+	if name == "uci" {
+		return "lan", nil
+	}
+	for _, n := range gNotations {
+		if name == n {
+			return n, nil
+		}
+	}
+	return "", fmt.Errorf("unknown notation %q, use one of %s", name, strings.Join(gNotations, ", "))
+}
+
+// Replace the English piece letters of a SAN move with the given letters.
+func localizeSAN(san string, letters []string) string {
+	var sb strings.Builder
+	for i := 0; i < len(san); i++ {
+		if j := strings.IndexByte("KQRBN", san[i]); j >= 0 {
+			sb.WriteString(letters[j])
+		} else {
+			sb.WriteByte(san[i])
+		}
+	}
+	return sb.String()
+}
+
+// Piece letter of descriptive notation, P for pawns.
+func descriptiveLetter(pieceType chess.PieceType) string {
+	if pieceType == chess.Pawn {
+		return "P"
+	}
+	return strings.ToUpper(pieceType.String())
+}
+
+// Square in descriptive notation like "KB3", with ranks counted from the mover's side.
+func descriptiveSquare(sq chess.Square, color chess.Color) string {
+	rank := int(sq.Rank()) + 1
+	if color == chess.Black {
+		rank = 8 - int(sq.Rank())
+	}
+	return gDescriptiveFiles[sq.File()] + strconv.Itoa(rank)
+}
+
+// Descriptive move without the check suffix. The destination square of a
+// capture and the origin square are written only when asked for.
+func descriptiveText(pos *chess.Position, m *chess.Move, withTo, withFrom bool) string {
+	switch {
+	case m.HasTag(chess.KingSideCastle):
+		return "O-O"
+	case m.HasTag(chess.QueenSideCastle):
+		return "O-O-O"
+	}
+	piece := pos.Board().Piece(m.S1())
+	text := descriptiveLetter(piece.Type())
+	if withFrom {
+		text += "/" + descriptiveSquare(m.S1(), piece.Color())
+	}
+	if m.HasTag(chess.Capture) || m.HasTag(chess.EnPassant) {
+		captured := chess.Pawn
+		if !m.HasTag(chess.EnPassant) {
+			captured = pos.Board().Piece(m.S2()).Type()
+		}
+		text += "x" + descriptiveLetter(captured)
+		if withTo {
+			text += "/" + descriptiveSquare(m.S2(), piece.Color())
+		}
+	} else {
+		text += "-" + descriptiveSquare(m.S2(), piece.Color())
+	}
+	if m.Promo() != chess.NoPieceType {
+		text += "=" + descriptiveLetter(m.Promo())
+	}
+	return text
+}
+
+// Move in old-style descriptive notation like "N-KB3" or "PxP", adding
+// squares only as needed to tell it apart from the other valid moves.
+func descriptiveMove(pos *chess.Position, m *chess.Move) string {
+	var text string
+	for _, detail := range [][2]bool{{false, false}, {true, false}, {true, true}} {
+		text = descriptiveText(pos, m, detail[0], detail[1])
+		unique := true
+		for _, other := range pos.ValidMoves() {
+			if other.String() != m.String() && descriptiveText(pos, other, detail[0], detail[1]) == text {
+				unique = false
+				break
+			}
+		}
+		if unique {
+			break
+		}
+	}
+	if m.HasTag(chess.Check) {
+		if pos.Update(m).Status() == chess.Checkmate {
+			return text + " mate"
+		}
+		return text + " ch"
+	}
+	return text
+}
+
+// Move in the notation chosen with --notation or /notation.
+func formatMove(pos *chess.Position, m *chess.Move) string {
+	switch gNotation {
+	case "lan":
+		return chess.Encoder.Encode(chess.LongAlgebraicNotation{}, pos, m)
+	case "descriptive":
+		return descriptiveMove(pos, m)
+	}
+	san := chess.Encoder.Encode(chess.AlgebraicNotation{}, pos, m)
+	if letters, ok := gPieceLetters[gNotation]; ok {
+		return localizeSAN(san, letters)
+	}
+	return san
+}
+
+// Strip the check marks off a typed or formatted move.
+func trimCheck(move string) string {
+	move = strings.TrimRight(strings.TrimSpace(move), "+#!?")
+	move = strings.TrimSuffix(move, "mate")
+	move = strings.TrimSuffix(move, "ch")
+	return strings.ReplaceAll(move, " ", "")
+}
+
+// Replace white and black figurines with English piece letters.
+var gFigurineReplacer = strings.NewReplacer("♔", "K", "♕", "Q", "♖", "R", "♗", "B", "♘", "N",
+	"♚", "K", "♛", "Q", "♜", "R", "♝", "B", "♞", "N", "♙", "", "♟", "")
+
+// Decode a typed move in the chosen notation, falling back to English SAN
+// and to long algebraic notation like "e2e4".
+func decodeMove(pos *chess.Position, input string) (*chess.Move, error) {
+	typed := trimCheck(input)
+	if typed == "" {
+		return nil, errors.New("no move given")
+	}
+
+	// Match the valid moves as printed, then ignoring the letter case.
+	var folded []*chess.Move
+	for _, m := range pos.ValidMoves() {
+		text := trimCheck(formatMove(pos, m))
+		if text == typed {
+			return m, nil
+		}
+		if strings.EqualFold(text, typed) {
+			folded = append(folded, m)
+		}
+	}
+	if len(folded) == 1 {
+		return folded[0], nil
+	}
+
+	if m, err := (chess.AlgebraicNotation{}).Decode(pos, gFigurineReplacer.Replace(typed)); err == nil {
+		return m, nil
+	}
+	if m, err := (chess.LongAlgebraicNotation{}).Decode(pos, strings.ToLower(typed)); err == nil {
+		return m, nil
+	}
+	return nil, fmt.Errorf("%w %q", errNoLegalMove, input)
+}
+
+// Game score in the chosen notation like "1. e4 e5 2. Nf3".
+func formatMoves(game *chess.Game) string {
+	moves := game.Moves()
+	positions := game.Positions()
+	moveNum := fullMoveNumber(positions[0])
+	var sb strings.Builder
+	for i, m := range moves {
+		pos := positions[i]
+		if pos.Turn() == chess.White {
+			sb.WriteString(strconv.Itoa(moveNum) + ". ")
+		} else if i == 0 {
+			sb.WriteString(strconv.Itoa(moveNum) + "... ")
+		}
+		sb.WriteString(formatMove(pos, m) + " ")
+		if pos.Turn() == chess.Black {
+			moveNum++
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// Answer "/notation [name]", changing the notation if a name is given.
+func setNotation(args []string) {
+	if len(args) > 1 {
+		fmt.Println("Usage:", gConsole.Bold(gConsole.Yellow("/notation ["+strings.Join(gNotations, "|")+"]")))
+		return
+	}
+	if len(args) == 1 {
+		notation, err := parseNotation(args[0])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		gNotation = notation
+	}
+	fmt.Println("Moves are written in", gConsole.Bold(gConsole.Yellow(gNotation)), "notation.")
+}
+
+// Answer "/moves" with the moves played so far. Reading back the moves
+// of a blind game is a peek.
+func showMoves(game *chess.Game) {
+	if len(game.Moves()) == 0 {
+		fmt.Println("No moves played yet.")
+		return
+	}
+	countPeek()
+	fmt.Println(formatMoves(game))
+}
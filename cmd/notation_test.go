@@ -0,0 +1,215 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/abperiasamy/chess"
+)
+
+// Use the notation for the test, restoring the current one afterwards.
+func useNotation(t *testing.T, notation string) {
+	saved := gNotation
+	gNotation = notation
+	t.Cleanup(func() { gNotation = saved })
+}
+
+func TestParseNotation(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"san", "san"},
+		{"LAN", "lan"},
+		{"uci", "lan"},
+		{"Figurine", "figurine"},
+		{"de", "de"},
+	}
+	for _, test := range tests {
+		if got, err := parseNotation(test.name); err != nil || got != test.want {
+			t.Errorf("parseNotation(%q) = %q, %v, want %q", test.name, got, err, test.want)
+		}
+	}
+	if _, err := parseNotation("klingon"); err == nil {
+		t.Error("parseNotation(\"klingon\") succeeded, want an error")
+	}
+}
+
+func TestFormatMove(t *testing.T) {
+	tests := []struct {
+		notation, moves, want string // The last of the moves is formatted.
+	}{
+		{"san", "g1f3", "Nf3"},
+		{"lan", "g1f3", "g1f3"},
+		{"figurine", "g1f3", "♘f3"},
+		{"de", "g1f3", "Sf3"},
+		{"fr", "e2e4 e7e5 d1h5", "Dh5"},
+		{"nl", "e2e4 e7e5 g1f3 b8c6 f1b5", "Lb5"},
+		{"de", "e2e4 d7d5 e4d5", "exd5"},
+		{"de", "e2e4 e7e5 f1c4 b8c6 d1h5 g8f6 h5f7", "Dxf7#"},
+		{"descriptive", "e2e4", "P-K4"},
+		{"descriptive", "e2e4 e7e5", "P-K4"},
+		{"descriptive", "e2e4 e7e5 g1f3", "N-KB3"},
+		{"descriptive", "e2e4 d7d5 e4d5", "PxP"},
+		{"descriptive", "e2e4 d7d5 e4d5 d8d5 b1c3", "N-QB3"},
+		{"descriptive", "e2e4 d7d5 e4d5 d8d5 b1c3 d5e5", "Q-K4 ch"},
+		{"descriptive", "e2e4 e7e5 f1c4 b8c6 d1h5 g8f6 h5f7", "QxP/KB7 mate"}, // The queen can take three pawns.
+		{"descriptive", "e2e4 e7e5 g1f3 b8c6 f1c4 g8f6 e1g1", "O-O"},
+		{"descriptive", "g1f3 g8f6 b1c3 b8c6 c3e4 c6e5 e4g5", "N/K4-KN5"}, // Both knights reach KN5.
+	}
+	for _, test := range tests {
+		useNotation(t, test.notation)
+		game := playLAN(t, test.moves)
+		positions, moves := game.Positions(), game.Moves()
+		last := len(moves) - 1
+		if got := formatMove(positions[last], moves[last]); got != test.want {
+			t.Errorf("formatMove in %s after %q = %q, want %q", test.notation, test.moves, got, test.want)
+		}
+	}
+}
+
+func TestDescriptiveDisambiguation(t *testing.T) {
+	useNotation(t, "descriptive")
+	// Both knights can go to Q2 and both center pawns can take.
+	game := playLAN(t, "e2e4 d7d5 c2c4 e7e6 b1c3 g8f6 g1f3")
+	pos := game.Position()
+	want := map[string]bool{}
+	for _, m := range pos.ValidMoves() {
+		text := formatMove(pos, m)
+		if want[text] {
+			t.Errorf("two moves are written %q", text)
+		}
+		want[text] = true
+	}
+	for _, text := range []string{"PxP/K5", "PxP/QB5", "N/QN1-Q2", "N/KB3-Q2", "NxP"} {
+		if !want[text] {
+			var texts []string
+			for text := range want {
+				texts = append(texts, text)
+			}
+			t.Errorf("no move is written %q, moves are %s", text, strings.Join(texts, " "))
+		}
+	}
+}
+
+func TestDecodeMove(t *testing.T) {
+	tests := []struct {
+		notation, moves, input, want string // The move decoded is compared in LAN.
+	}{
+		{"san", "", "Nf3", "g1f3"},
+		{"san", "", "nf3", "g1f3"},
+		{"san", "", "e2e4", "e2e4"},
+		{"san", "", "E2E4", "e2e4"},
+		{"lan", "", "Nf3", "g1f3"},
+		{"figurine", "", "♘f3", "g1f3"},
+		{"de", "", "Sf3", "g1f3"},
+		{"de", "", "Nf3", "g1f3"},
+		{"fr", "e2e4 e7e5", "Dh5", "d1h5"},
+		{"descriptive", "", "N-KB3", "g1f3"},
+		{"descriptive", "", "p-k4", "e2e4"},
+		{"descriptive", "e2e4 d7d5", "PxP", "e4d5"},
+		{"descriptive", "e2e4 d7d5 e4d5 d8d5 b1c3", "Q-K4 ch", "d5e5"},
+		{"san", "e2e4 e7e5 f1c4 b8c6 d1h5 g8f6", "Qxf7#", "h5f7"},
+		{"san", "e2e4 e7e5 f1c4 b8c6 d1h5 g8f6", "Qxf7", "h5f7"},
+		{"san", "e2e4 e7e5 g1f3 b8c6 f1c4 g8f6", "O-O", "e1g1"},
+	}
+	for _, test := range tests {
+		useNotation(t, test.notation)
+		pos := playLAN(t, test.moves).Position()
+		m, err := decodeMove(pos, test.input)
+		if err != nil {
+			t.Errorf("decodeMove(%q) in %s failed: %v", test.input, test.notation, err)
+			continue
+		}
+		if got := chess.Encoder.Encode(chess.LongAlgebraicNotation{}, pos, m); got != test.want {
+			t.Errorf("decodeMove(%q) in %s = %s, want %s", test.input, test.notation, got, test.want)
+		}
+	}
+
+	useNotation(t, "san")
+	for _, input := range []string{"", "Nf4", "e5", "O-O", "xyz"} {
+		if _, err := decodeMove(chess.NewGame().Position(), input); err == nil {
+			t.Errorf("decodeMove(%q) succeeded, want an error", input)
+		} else if input != "" && !errors.Is(err, errNoLegalMove) {
+			t.Errorf("decodeMove(%q) error %v, want %v", input, err, errNoLegalMove)
+		}
+	}
+}
+
+func TestFormatMoves(t *testing.T) {
+	useNotation(t, "san")
+	if got, want := formatMoves(playLAN(t, "e2e4 e7e5 g1f3")), "1. e4 e5 2. Nf3"; got != want {
+		t.Errorf("formatMoves = %q, want %q", got, want)
+	}
+	fen, _ := chess.FEN("4k3/8/8/8/8/8/4P3/4K3 b - - 0 12")
+	game := chess.NewGame(fen)
+	for _, lan := range []string{"e8d7", "e2e4"} {
+		m, _ := chess.LongAlgebraicNotation{}.Decode(game.Position(), lan)
+		game.Move(m)
+	}
+	if got, want := formatMoves(game), "12... Kd7 13. e4"; got != want {
+		t.Errorf("formatMoves from black = %q, want %q", got, want)
+	}
+}
+
+func TestShowMovesPeek(t *testing.T) {
+	useNotation(t, "san")
+	defer func(peeks int, visual bool) { gPeeks, gVisual = peeks, visual }(gPeeks, gVisual)
+	tests := []struct {
+		moves  string
+		visual bool
+		peeks  int
+	}{
+		{"e2e4 e7e5", false, 1},
+		{"e2e4 e7e5", true, 0}, // Nothing hidden to peek at.
+		{"", false, 0},
+	}
+	for _, test := range tests {
+		gPeeks, gVisual = 0, test.visual
+		captureOutput(t, func() { showMoves(playLAN(t, test.moves)) })
+		if gPeeks != test.peeks {
+			t.Errorf("/moves after %q with visual %v counted %d peeks, want %d", test.moves, test.visual, gPeeks, test.peeks)
+		}
+	}
+}
+
+func TestMoveCompletion(t *testing.T) {
+	defer func(game *chess.Game) { gGame = game }(gGame)
+	tests := []struct {
+		notation, moves, want string
+	}{
+		{"descriptive", "e2e4 d7d5 e4d5 d8d5 b1c3", "Q-K4"},
+		{"descriptive", "e2e4 e7e5 f1c4 b8c6 d1h5 g8f6", "QxP/KB7"},
+		{"san", "e2e4 e7e5 f1c4 b8c6 d1h5 g8f6", "Qxf7#"},
+	}
+	for _, test := range tests {
+		useNotation(t, test.notation)
+		gGame = playLAN(t, test.moves)
+		found := false
+		for _, item := range validMovesConstructor()("") {
+			if strings.Contains(item, " ") {
+				t.Errorf("%s completion %q has a space", test.notation, item)
+			}
+			found = found || item == test.want
+		}
+		if !found {
+			t.Errorf("%s completion after %q lacks %q", test.notation, test.moves, test.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/abperiasamy/chess"
+)
+
+// ECO classification of the openings by position, from
+// https://github.com/lichess-org/chess-openings
+//
+//go:embed eco.tsv
+var gECOData string
+
+// A named opening or variation.
+type chessOpening struct {
+	eco  string
+	name string
+}
+
+func (o *chessOpening) String() string {
+	return o.eco + " " + o.name
+}
+
+var (
+	gOpenings     map[string]*chessOpening // Openings by EPD position.
+	gOpeningsOnce sync.Once
+)
+
+// Index the embedded ECO database by position on first use.
+func openings() map[string]*chessOpening {
+	gOpeningsOnce.Do(func() {
+		gOpenings = make(map[string]*chessOpening)
+		for _, line := range strings.Split(gECOData, "\n")[1:] {
+			fields := strings.Split(line, "\t")
+			if len(fields) != 3 {
+				continue
+			}
+			if _, ok := gOpenings[fields[2]]; !ok { // The first name of a transposition wins.
+				gOpenings[fields[2]] = &chessOpening{eco: fields[0], name: fields[1]}
+			}
+		}
+	})
+	return gOpenings
+}
+
+// EPD of the position, with the en passant square only if the capture is possible.
+func positionEPD(pos *chess.Position) string {
+	fields := strings.Fields(pos.String())[:4]
+	if file := enPassantFile(pos); file < 0 {
+		fields[3] = "-"
+	}
+	return strings.Join(fields, " ")
+}
+
+// The most recent named opening the game went through, nil if none.
+func gameOpening(game *chess.Game) *chessOpening {
+	positions := game.Positions()
+	for i := len(positions) - 1; i >= 0; i-- {
+		if opening, ok := openings()[positionEPD(positions[i])]; ok {
+			return opening
+		}
+	}
+	return nil
+}
+
+// Announce the opening once the game enters a new opening or variation.
+func announceOpening(game *chess.Game) {
+	opening := gameOpening(game)
+	if opening == nil || opening == gOpening {
+		return
+	}
+	gOpening = opening
+	fmt.Println(gConsole.Bold(gConsole.Cyan(opening)))
+}
+
+// Show the opening of the game.
+func showOpening(game *chess.Game) {
+	if opening := gameOpening(game); opening != nil {
+		fmt.Println("Opening:", gConsole.Bold(gConsole.Cyan(opening)))
+	} else {
+		fmt.Println("No known opening yet.")
+	}
+}
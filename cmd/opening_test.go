@@ -0,0 +1,65 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import "testing"
+
+func TestPositionEPD(t *testing.T) {
+	tests := []struct {
+		moves string
+		epd   string
+	}{
+		{"", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -"},
+		{"e2e4", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq -"},
+		{"e2e4 d7d5 e4e5 f7f5", "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6"},
+	}
+	for _, test := range tests {
+		if epd := positionEPD(playLAN(t, test.moves).Position()); epd != test.epd {
+			t.Errorf("positionEPD after %q = %q, want %q", test.moves, epd, test.epd)
+		}
+	}
+}
+
+func TestGameOpening(t *testing.T) {
+	tests := []struct {
+		moves string
+		want  string // Empty for no opening.
+	}{
+		{"", ""},
+		{"e2e4 c7c5", "B20 Sicilian Defense"},
+		{"e2e4 e7e5 g1f3 b8c6 f1b5", "C60 Ruy Lopez"},
+		{"e2e4 e7e5 g1f3 b8c6 f1c4", "C50 Italian Game"},
+		{"d2d4 d7d5 c2c4 e7e6", "D30 Queen's Gambit Declined"},
+		{"c2c4 e7e6 d2d4 d7d5", "D30 Queen's Gambit Declined"}, // Transposed from the English.
+		{"c2c4", "A10 English Opening"},
+	}
+	for _, test := range tests {
+		got := ""
+		if opening := gameOpening(playLAN(t, test.moves)); opening != nil {
+			got = opening.String()
+		}
+		if got != test.want {
+			t.Errorf("gameOpening after %q = %q, want %q", test.moves, got, test.want)
+		}
+	}
+
+	// A move out of the database keeps the last named opening.
+	game := playLAN(t, "e2e4 c7c5 a2a3 a7a6 h2h3 h7h6")
+	if opening := gameOpening(game); opening == nil || opening.eco[0] != 'B' {
+		t.Errorf("gameOpening out of book = %v, want a Sicilian", opening)
+	}
+}
@@ -0,0 +1,185 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+)
+
+// Piece names in words, in the order they are listed.
+var gPieceNames = []struct {
+	pieceType chess.PieceType
+	name      string
+}{
+	{chess.King, "King"}, {chess.Queen, "Queen"}, {chess.Rook, "Rook"},
+	{chess.Bishop, "Bishop"}, {chess.Knight, "Knight"}, {chess.Pawn, "Pawn"},
+}
+
+// Name of the piece type, plural for more than one piece.
+func pieceTypeName(pieceType chess.PieceType, count int) string {
+	for _, p := range gPieceNames {
+		if p.pieceType == pieceType {
+			if count > 1 {
+				return p.name + "s"
+			}
+			return p.name
+		}
+	}
+	return ""
+}
+
+// Piece of the given color and type.
+func coloredPiece(color chess.Color, pieceType chess.PieceType) chess.Piece {
+	for _, piece := range []chess.Piece{chess.WhiteKing, chess.WhiteQueen, chess.WhiteRook, chess.WhiteBishop, chess.WhiteKnight, chess.WhitePawn,
+		chess.BlackKing, chess.BlackQueen, chess.BlackRook, chess.BlackBishop, chess.BlackKnight, chess.BlackPawn} {
+		if piece.Color() == color && piece.Type() == pieceType {
+			return piece
+		}
+	}
+	return chess.NoPiece
+}
+
+// Parse a piece type like "knight", "knights" or "N".
+func parsePieceType(word string) (chess.PieceType, bool) {
+	word = strings.ToLower(word)
+	for _, p := range gPieceNames {
+		name := strings.ToLower(p.name)
+		if word == name || word == name+"s" || (word != "" && word == p.pieceType.String()) || (p.pieceType == chess.Pawn && word == "p") {
+			return p.pieceType, true
+		}
+	}
+	return chess.NoPieceType, false
+}
+
+// Parse a color like "white" or "black".
+func parseColor(word string) (chess.Color, bool) {
+	switch strings.ToLower(word) {
+	case "white", "w":
+		return chess.White, true
+	case "black", "b":
+		return chess.Black, true
+	}
+	return chess.NoColor, false
+}
+
+// Squares of the given piece, in board order from a1 to h8.
+func pieceSquares(board *chess.Board, piece chess.Piece) (squares []chess.Square) {
+	for sq, p := range board.SquareMap() {
+		if p == piece {
+			squares = append(squares, sq)
+		}
+	}
+	sort.Slice(squares, func(i, j int) bool { return squares[i] < squares[j] })
+	return squares
+}
+
+// Join the square names like "b1, g1".
+func squareNames(squares []chess.Square) string {
+	names := make([]string, len(squares))
+	for i, sq := range squares {
+		names[i] = sq.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// Square of its name like "e4", NoSquare if not a square.
+func strToSquare(name string) chess.Square {
+	if len(name) != 2 || name[0] < 'a' || name[0] > 'h' || name[1] < '1' || name[1] > '8' {
+		return chess.NoSquare
+	}
+	return chess.Square(8*int(name[1]-'1') + int(name[0]-'a'))
+}
+
+// Count a board query as a peek, unless the board is shown anyway.
+func countPeek() {
+	if !gVisual {
+		gPeeks++
+	}
+}
+
+// Answer "/piece <square>" with the piece standing on the square.
+func showPiece(game *chess.Game, args []string) {
+	if len(args) != 1 || strToSquare(strings.ToLower(args[0])) == chess.NoSquare {
+		fmt.Println("Usage:", gConsole.Bold(gConsole.Yellow("/piece <square>")), "like /piece e4")
+		return
+	}
+	sq := strToSquare(strings.ToLower(args[0]))
+	countPeek()
+
+	piece := game.Position().Board().Piece(sq)
+	if piece == chess.NoPiece {
+		fmt.Println(gConsole.Bold(sq.String()), "is empty.")
+		return
+	}
+	fmt.Println(gConsole.Bold(sq.String()).String()+":", gConsole.Bold(gConsole.Yellow(piece.Color().Name()+" "+pieceTypeName(piece.Type(), 1))))
+}
+
+// Answer "/where [white|black] <piece>" with the squares of the pieces.
+// The color defaults to the side to move.
+func showWhere(game *chess.Game, args []string) {
+	color := game.Position().Turn()
+	ok := len(args) == 1
+	if len(args) == 2 {
+		color, ok = parseColor(args[0])
+		args = args[1:]
+	}
+	var pieceType chess.PieceType
+	if ok {
+		pieceType, ok = parsePieceType(args[0])
+	}
+	if !ok {
+		fmt.Println("Usage:", gConsole.Bold(gConsole.Yellow("/where [white|black] <piece>")), "like /where knights")
+		return
+	}
+	countPeek()
+
+	squares := pieceSquares(game.Position().Board(), coloredPiece(color, pieceType))
+	if len(squares) == 0 {
+		fmt.Println("No", color.Name(), pieceTypeName(pieceType, 2), "left.")
+		return
+	}
+	fmt.Println(gConsole.Bold(gConsole.Yellow(color.Name()+" "+pieceTypeName(pieceType, len(squares)))).String()+":", squareNames(squares))
+}
+
+// Answer "/list [white|black]" with all the pieces of one or both sides.
+func showList(game *chess.Game, args []string) {
+	colors := []chess.Color{chess.White, chess.Black}
+	if len(args) > 0 {
+		color, ok := parseColor(args[0])
+		if !ok || len(args) > 1 {
+			fmt.Println("Usage:", gConsole.Bold(gConsole.Yellow("/list [white|black]")))
+			return
+		}
+		colors = []chess.Color{color}
+	}
+	countPeek()
+
+	board := game.Position().Board()
+	for _, color := range colors {
+		var groups []string
+		for _, p := range gPieceNames {
+			if squares := pieceSquares(board, coloredPiece(color, p.pieceType)); len(squares) > 0 {
+				groups = append(groups, pieceTypeName(p.pieceType, len(squares))+" "+squareNames(squares))
+			}
+		}
+		fmt.Println(gConsole.Bold(gConsole.Yellow(color.Name())).String()+":", strings.Join(groups, "; "))
+	}
+}
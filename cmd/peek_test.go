@@ -0,0 +1,138 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/abperiasamy/chess"
+)
+
+// Run the function and return what it printed.
+func captureOutput(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	f()
+	w.Close()
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestStrToSquare(t *testing.T) {
+	tests := []struct {
+		name string
+		sq   chess.Square
+	}{
+		{"a1", chess.A1}, {"e4", chess.E4}, {"h8", chess.H8},
+		{"i1", chess.NoSquare}, {"a9", chess.NoSquare}, {"e", chess.NoSquare}, {"e44", chess.NoSquare}, {"E4", chess.NoSquare},
+	}
+	for _, test := range tests {
+		if sq := strToSquare(test.name); sq != test.sq {
+			t.Errorf("strToSquare(%q) = %v, want %v", test.name, sq, test.sq)
+		}
+	}
+}
+
+func TestParsePieceType(t *testing.T) {
+	tests := []struct {
+		word      string
+		pieceType chess.PieceType
+	}{
+		{"knight", chess.Knight}, {"Knights", chess.Knight}, {"N", chess.Knight}, {"n", chess.Knight},
+		{"queen", chess.Queen}, {"rooks", chess.Rook}, {"BISHOP", chess.Bishop}, {"k", chess.King},
+		{"pawns", chess.Pawn}, {"p", chess.Pawn},
+		{"horse", chess.NoPieceType}, {"queenss", chess.NoPieceType}, {"", chess.NoPieceType},
+	}
+	for _, test := range tests {
+		pieceType, ok := parsePieceType(test.word)
+		if pieceType != test.pieceType || ok != (test.pieceType != chess.NoPieceType) {
+			t.Errorf("parsePieceType(%q) = %v, %v, want %v", test.word, pieceType, ok, test.pieceType)
+		}
+	}
+}
+
+func TestPieceTypeName(t *testing.T) {
+	if name := pieceTypeName(chess.Bishop, 1); name != "Bishop" {
+		t.Errorf("pieceTypeName(Bishop, 1) = %q, want Bishop", name)
+	}
+	if name := pieceTypeName(chess.Bishop, 2); name != "Bishops" {
+		t.Errorf("pieceTypeName(Bishop, 2) = %q, want Bishops", name)
+	}
+}
+
+func TestPeekQueries(t *testing.T) {
+	defer func(peeks int, visual bool) { gPeeks, gVisual = peeks, visual }(gPeeks, gVisual)
+	gVisual = false
+	game := playLAN(t, "e2e4 e7e5 g1f3")
+	tests := []struct {
+		show  func(*chess.Game, []string)
+		args  []string
+		want  string
+		peeks int // Counted peeks, 0 for a usage error.
+	}{
+		{showPiece, []string{"e4"}, "e4: White Pawn\n", 1},
+		{showPiece, []string{"F3"}, "f3: White Knight\n", 1},
+		{showPiece, []string{"e3"}, "e3 is empty.\n", 1},
+		{showPiece, []string{"z9"}, "Usage: /piece <square> like /piece e4\n", 0},
+		{showWhere, []string{"black", "queen"}, "Black Queen: d8\n", 1},
+		{showWhere, []string{"knights"}, "Black Knights: b8, g8\n", 1}, // Black to move.
+		{showWhere, []string{"white", "knights"}, "White Knights: b1, f3\n", 1},
+		{showWhere, []string{"w", "K"}, "White King: e1\n", 1},
+		{showWhere, []string{"white", "bishop", "c1"}, "Usage: /where [white|black] <piece> like /where knights\n", 0},
+		{showWhere, []string{"red", "queen"}, "Usage: /where [white|black] <piece> like /where knights\n", 0},
+		{showWhere, nil, "Usage: /where [white|black] <piece> like /where knights\n", 0},
+		{showList, []string{"white"}, "White: King e1; Queen d1; Rooks a1, h1; Bishops c1, f1; Knights b1, f3; " +
+			"Pawns a2, b2, c2, d2, f2, g2, h2, e4\n", 1},
+		{showList, []string{"white", "black"}, "Usage: /list [white|black]\n", 0},
+	}
+	for _, test := range tests {
+		gPeeks = 0
+		if out := captureOutput(t, func() { test.show(game, test.args) }); out != test.want {
+			t.Errorf("peek %q = %q, want %q", test.args, out, test.want)
+		}
+		if gPeeks != test.peeks {
+			t.Errorf("peek %q counted %d peeks, want %d", test.args, gPeeks, test.peeks)
+		}
+	}
+
+	// Nothing is hidden with the board on display.
+	gVisual, gPeeks = true, 0
+	captureOutput(t, func() {
+		showPiece(game, []string{"e4"})
+		showWhere(game, []string{"knights"})
+		showList(game, nil)
+	})
+	if gPeeks != 0 {
+		t.Errorf("queries with the board shown counted %d peeks", gPeeks)
+	}
+
+	game = gameFromFEN(t, "4k3/8/8/8/8/8/8/4K2R w K - 0 1")
+	if out := captureOutput(t, func() { showWhere(game, []string{"black", "rooks"}) }); out != "No Black Rooks left.\n" {
+		t.Errorf("where black rooks = %q, want none left", out)
+	}
+}
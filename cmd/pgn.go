@@ -0,0 +1,654 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abperiasamy/chess"
+)
+
+const gPGNLineWidth = 79 // PGN export format keeps lines under 80 columns.
+
+// A move of the PGN move text with the annotations around it.
+type pgnMove struct {
+	san    string
+	before []string  // Comments ahead of the move, at the start of a game or variation.
+	after  []pgnNote // NAGs, comments and variations following the move, in order.
+}
+
+// A NAG, a comment or a variation following a move. A variation is an
+// alternative to the move it follows.
+type pgnNote struct {
+	nag       int
+	suffix    bool // NAG written as a move suffix like "!?".
+	comment   string
+	variation []*pgnMove
+}
+
+// A game of a PGN file.
+type pgnGame struct {
+	tags     []*chess.TagPair
+	moves    []*pgnMove // Main line.
+	comments []string   // Comments after the last move of a game without moves.
+	result   string
+}
+
+// Move suffix annotations and their NAGs.
+var gSuffixNAGs = map[string]int{"!": 1, "?": 2, "!!": 3, "??": 4, "!?": 5, "?!": 6}
+
+// Move suffix of the NAG, empty if it has none.
+func nagSuffix(nag int) string {
+	for suffix, n := range gSuffixNAGs {
+		if n == nag {
+			return suffix
+		}
+	}
+	return ""
+}
+
+var gPGNResults = map[string]bool{"1-0": true, "0-1": true, "1/2-1/2": true, "*": true}
+
+// Kinds of PGN tokens.
+const (
+	pgnEOF = iota
+	pgnTag
+	pgnComment
+	pgnOpen
+	pgnClose
+	pgnNAG
+	pgnResult
+	pgnSAN
+)
+
+type pgnToken struct {
+	kind  int
+	text  string // Tag name, comment, result or move.
+	value string // Tag value.
+	nag   int    // NAG, also of a move suffix like "!?".
+}
+
+// Tokenizer and parser of PGN text, following the PGN standard's
+// import format.
+type pgnParser struct {
+	text   string
+	pos    int
+	line   int
+	peeked *pgnToken
+}
+
+func (p *pgnParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+// Advance past the byte, counting lines.
+func (p *pgnParser) advance() {
+	if p.text[p.pos] == '\n' {
+		p.line++
+	}
+	p.pos++
+}
+
+// Skip white space and escaped lines, which start with a "%".
+func (p *pgnParser) skipSpace() {
+	for p.pos < len(p.text) {
+		c := p.text[p.pos]
+		switch {
+		case c == '%' && (p.pos == 0 || p.text[p.pos-1] == '\n'):
+			for p.pos < len(p.text) && p.text[p.pos] != '\n' {
+				p.pos++
+			}
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f':
+			p.advance()
+		default:
+			return
+		}
+	}
+}
+
+func isSymbolChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("_+#=:-/.", c) >= 0
+}
+
+var moveNumRegex = regexp.MustCompile(`^\d+(\.+|$)`)
+
+// Next token of the text.
+func (p *pgnParser) next() (pgnToken, error) {
+	if p.peeked != nil {
+		tok := *p.peeked
+		p.peeked = nil
+		return tok, nil
+	}
+	for {
+		p.skipSpace()
+		if p.pos == len(p.text) {
+			return pgnToken{kind: pgnEOF}, nil
+		}
+		start := p.pos
+		switch c := p.text[p.pos]; c {
+		case '[':
+			return p.tag()
+		case '{':
+			end := strings.IndexByte(p.text[p.pos:], '}')
+			if end < 0 {
+				return pgnToken{}, p.errorf("unterminated comment")
+			}
+			for p.pos < start+end+1 {
+				p.advance()
+			}
+			return pgnToken{kind: pgnComment, text: p.text[start+1 : start+end]}, nil
+		case ';': // Comment to the end of the line.
+			for p.pos < len(p.text) && p.text[p.pos] != '\n' {
+				p.pos++
+			}
+			return pgnToken{kind: pgnComment, text: strings.TrimSpace(p.text[start+1 : p.pos])}, nil
+		case '(':
+			p.pos++
+			return pgnToken{kind: pgnOpen}, nil
+		case ')':
+			p.pos++
+			return pgnToken{kind: pgnClose}, nil
+		case '*':
+			p.pos++
+			return pgnToken{kind: pgnResult, text: "*"}, nil
+		case '$':
+			p.pos++
+			for p.pos < len(p.text) && p.text[p.pos] >= '0' && p.text[p.pos] <= '9' {
+				p.pos++
+			}
+			nag, err := strconv.Atoi(p.text[start+1 : p.pos])
+			if err != nil {
+				return pgnToken{}, p.errorf("invalid NAG %q", p.text[start:p.pos])
+			}
+			return pgnToken{kind: pgnNAG, nag: nag}, nil
+		default:
+			if !isSymbolChar(c) {
+				return pgnToken{}, p.errorf("unexpected %q", c)
+			}
+		}
+
+		for p.pos < len(p.text) && isSymbolChar(p.text[p.pos]) {
+			p.pos++
+		}
+		symbol := p.text[start:p.pos]
+		if gPGNResults[symbol] {
+			return pgnToken{kind: pgnResult, text: symbol}, nil
+		}
+		san := moveNumRegex.ReplaceAllString(symbol, "")
+		if strings.Trim(san, ".") == "" { // Move number.
+			continue
+		}
+		suffix := p.pos
+		for p.pos < len(p.text) && (p.text[p.pos] == '!' || p.text[p.pos] == '?') {
+			p.pos++
+		}
+		nag, ok := gSuffixNAGs[p.text[suffix:p.pos]]
+		if !ok && p.pos > suffix {
+			return pgnToken{}, p.errorf("invalid move annotation %q", p.text[suffix:p.pos])
+		}
+		if strings.HasPrefix(san, "0-0") { // Castling written with zeros.
+			san = strings.ReplaceAll(san, "0", "O")
+		}
+		return pgnToken{kind: pgnSAN, text: san, nag: nag}, nil
+	}
+}
+
+// Tag pair like [Event "Casual game"], unescaping \" and \\ in the value.
+func (p *pgnParser) tag() (pgnToken, error) {
+	p.pos++ // [
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.text) && isSymbolChar(p.text[p.pos]) {
+		p.pos++
+	}
+	name := p.text[start:p.pos]
+	p.skipSpace()
+	if name == "" || p.pos == len(p.text) || p.text[p.pos] != '"' {
+		return pgnToken{}, p.errorf("invalid tag pair")
+	}
+	p.pos++
+
+	var value strings.Builder
+	for {
+		if p.pos == len(p.text) || p.text[p.pos] == '\n' {
+			return pgnToken{}, p.errorf("unterminated value of tag %s", name)
+		}
+		c := p.text[p.pos]
+		p.pos++
+		if c == '"' {
+			break
+		}
+		if c == '\\' && p.pos < len(p.text) && (p.text[p.pos] == '"' || p.text[p.pos] == '\\') {
+			c = p.text[p.pos]
+			p.pos++
+		}
+		value.WriteByte(c)
+	}
+	p.skipSpace()
+	if p.pos == len(p.text) || p.text[p.pos] != ']' {
+		return pgnToken{}, p.errorf("tag %s is not closed", name)
+	}
+	p.pos++
+	return pgnToken{kind: pgnTag, text: name, value: value.String()}, nil
+}
+
+// Parse a line of moves up to the end of its variation, the result or the
+// next game. Returns the moves and the comments following no move.
+func (p *pgnParser) moveLine(game *pgnGame, variation bool) ([]*pgnMove, []string, error) {
+	var moves []*pgnMove
+	var pending []string
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return nil, nil, err
+		}
+		var last *pgnMove
+		if len(moves) > 0 {
+			last = moves[len(moves)-1]
+		}
+
+		switch tok.kind {
+		case pgnSAN:
+			move := &pgnMove{san: tok.text, before: pending}
+			if tok.nag > 0 {
+				move.after = append(move.after, pgnNote{nag: tok.nag, suffix: true})
+			}
+			moves = append(moves, move)
+			pending = nil
+		case pgnComment:
+			if tok.text == "" {
+				continue
+			}
+			if last == nil {
+				pending = append(pending, tok.text)
+			} else {
+				last.after = append(last.after, pgnNote{comment: tok.text})
+			}
+		case pgnNAG:
+			if last == nil {
+				return nil, nil, p.errorf("NAG $%d before any move", tok.nag)
+			}
+			last.after = append(last.after, pgnNote{nag: tok.nag})
+		case pgnOpen:
+			if last == nil {
+				return nil, nil, p.errorf("variation before any move")
+			}
+			line, comments, err := p.moveLine(game, true)
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(line) == 0 {
+				if len(comments) > 0 {
+					return nil, nil, p.errorf("variation without moves")
+				}
+				continue
+			}
+			last.after = append(last.after, pgnNote{variation: line})
+		case pgnClose:
+			if !variation {
+				return nil, nil, p.errorf("unexpected )")
+			}
+			return moves, pending, nil
+		case pgnResult:
+			if variation {
+				return nil, nil, p.errorf("result %s inside a variation", tok.text)
+			}
+			game.result = tok.text
+			return moves, pending, nil
+		case pgnTag, pgnEOF:
+			if variation {
+				return nil, nil, p.errorf("unterminated variation")
+			}
+			if tok.kind == pgnTag { // Next game without a result.
+				p.peeked = &tok
+			}
+			return moves, pending, nil
+		}
+	}
+}
+
+// Parse all the games of a PGN text.
+func parsePGN(text string) ([]*pgnGame, error) {
+	p := &pgnParser{text: text, line: 1}
+	var games []*pgnGame
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if tok.kind == pgnEOF {
+			return games, nil
+		}
+		game := &pgnGame{}
+		for ; tok.kind == pgnTag; tok, err = p.next() {
+			game.tags = append(game.tags, &chess.TagPair{Key: tok.text, Value: tok.value})
+		}
+		if err != nil {
+			return nil, err
+		}
+		p.peeked = &tok
+		if game.moves, game.comments, err = p.moveLine(game, false); err != nil {
+			return nil, err
+		}
+		games = append(games, game)
+	}
+}
+
+// Play out the main line of the game. Returns the game with the notes of
+// every ply, their moves in SAN, and one more for the comments of a game
+// without moves.
+func (g *pgnGame) game() (*chess.Game, []pgnMove, error) {
+	var sb strings.Builder
+	for _, tag := range g.tags {
+		if strings.EqualFold(tag.Key, "FEN") {
+			fmt.Fprintf(&sb, "[FEN \"%s\"]\n\n", tag.Value)
+		}
+	}
+	for _, move := range g.moves {
+		sb.WriteString(move.san + " ")
+	}
+	result := g.result
+	if result == "" {
+		result = "*"
+	}
+	sb.WriteString(result)
+
+	// Moves alone are safe for the chess package's PGN reader.
+	pgn, err := chess.PGN(strings.NewReader(sb.String()))
+	if err != nil {
+		return nil, nil, err
+	}
+	game := chess.NewGame(pgn, chess.TagPairs(g.tags))
+
+	notes := make([]pgnMove, len(g.moves))
+	positions := game.Positions()
+	for i, move := range g.moves {
+		for _, note := range move.after {
+			if note.variation == nil {
+				continue
+			}
+			if err := replayVariation(positions[i], note.variation); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	for i, move := range game.Moves() {
+		notes[i] = *g.moves[i]
+		notes[i].san = chess.Encoder.Encode(chess.AlgebraicNotation{}, positions[i], move)
+	}
+	if len(g.comments) > 0 {
+		notes = append(notes, pgnMove{before: g.comments})
+	}
+	return game, notes, nil
+}
+
+// Play out a variation from the position it starts at, with the variations
+// nested in it, and write its moves in SAN as the chess package does.
+func replayVariation(pos *chess.Position, moves []*pgnMove) error {
+	for _, move := range moves {
+		m, err := chess.AlgebraicNotation{}.Decode(pos, move.san)
+		if err != nil {
+			return fmt.Errorf("illegal move %s at move %d of a variation", move.san, fullMoveNumber(pos))
+		}
+		for _, note := range move.after {
+			if note.variation == nil {
+				continue
+			}
+			if err := replayVariation(pos, note.variation); err != nil {
+				return err
+			}
+		}
+		move.san = chess.Encoder.Encode(chess.AlgebraicNotation{}, pos, m)
+		pos = pos.Update(m)
+	}
+	return nil
+}
+
+// Read the first game of a PGN file with the notes of its plies.
+func readPGN(filename string) (*chess.Game, []pgnMove, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	games, err := parsePGN(string(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(games) == 0 {
+		return nil, nil, fmt.Errorf("no game")
+	}
+	return games[0].game()
+}
+
+// Notes holding the comments, comments[i] after the i-th ply when not empty.
+func commentNotes(comments []string) []pgnMove {
+	notes := make([]pgnMove, len(comments))
+	for i, comment := range comments {
+		if comment != "" {
+			notes[i].after = []pgnNote{{comment: comment}}
+		}
+	}
+	return notes
+}
+
+var pgnCommandRegex = map[string]*regexp.Regexp{}
+
+// Set the command named, like "[%eval 0.35]", in the ply's comments where
+// it stands, or else in its first comment after any NAGs. An empty command
+// removes it, and comments left empty are dropped.
+func setCommand(note pgnMove, name, command string) pgnMove {
+	r, ok := pgnCommandRegex[name]
+	if !ok {
+		r = regexp.MustCompile(`\[%` + name + `\s[^\]]*\]`)
+		pgnCommandRegex[name] = r
+	}
+
+	after := make([]pgnNote, 0, len(note.after)+1)
+	for _, n := range note.after {
+		if loc := r.FindStringIndex(n.comment); loc != nil {
+			before, rest := n.comment[:loc[0]], r.ReplaceAllString(n.comment[loc[1]:], "")
+			if command == "" { // Removed along with the space before it.
+				n.comment = strings.TrimSpace(strings.TrimRight(before, " ") + rest)
+			} else {
+				n.comment = before + command + rest
+			}
+			command = "" // Set.
+			if n.comment == "" {
+				continue
+			}
+		}
+		after = append(after, n)
+	}
+	if command != "" {
+		i := 0
+		for i < len(after) && after[i].variation == nil && after[i].comment == "" { // NAGs.
+			i++
+		}
+		if i < len(after) && after[i].comment != "" {
+			after[i].comment += " " + command
+		} else {
+			after = append(after[:i], append([]pgnNote{{comment: command}}, after[i:]...)...)
+		}
+	}
+	note.after = after
+	return note
+}
+
+// Clock of the %clk command in the ply's comments, -1 if it has none.
+func moveClock(note pgnMove) time.Duration {
+	for _, n := range note.after {
+		if clocks := parseClocks(n.comment); len(clocks) > 0 {
+			return clocks[0]
+		}
+	}
+	return -1
+}
+
+// Notes of the plies of the game being played, the loaded ones while the
+// game follows the loaded moves, with the current clocks.
+func gameNotes(game *chess.Game) []pgnMove {
+	moves := game.Moves()
+	positions := game.Positions()
+	notes := make([]pgnMove, len(moves))
+	loaded := true
+	for i, move := range moves {
+		san := chess.Encoder.Encode(chess.AlgebraicNotation{}, positions[i], move)
+		if loaded = loaded && i < len(gMoveNotes) && gMoveNotes[i].san == san; loaded {
+			notes[i] = gMoveNotes[i]
+		}
+		notes[i].san = san
+		if gClock != nil {
+			command := ""
+			if i < len(gClockHistory) && gClockHistory[i] >= 0 {
+				command = "[%clk " + formatClk(gClockHistory[i]) + "]"
+			}
+			notes[i] = setCommand(notes[i], "clk", command)
+		}
+	}
+	if loaded && len(gMoveNotes) == len(moves)+1 { // Comments of a game without moves.
+		notes = append(notes, gMoveNotes[len(moves)])
+	}
+	return notes
+}
+
+// Encode the game in PGN export format. comments[i], when present and
+// not empty, is written as a {comment} after the i-th ply.
+func encodePGN(game *chess.Game, comments []string) string {
+	return encodeAnnotatedPGN(game, commentNotes(comments))
+}
+
+// Encode the game in PGN export format with the NAGs, comments and
+// variations of notes[i] around the i-th ply.
+func encodeAnnotatedPGN(game *chess.Game, notes []pgnMove) string {
+	var sb strings.Builder
+	for _, tag := range game.TagPairs() {
+		value := strings.ReplaceAll(strings.ReplaceAll(tag.Value, `\`, `\\`), `"`, `\"`)
+		fmt.Fprintf(&sb, "[%s \"%s\"]\n", tag.Key, value)
+	}
+	sb.WriteString("\n")
+
+	moves := make([]*pgnMove, len(game.Moves()))
+	positions := game.Positions()
+	for i, move := range game.Moves() {
+		note := pgnMove{}
+		if i < len(notes) {
+			note = notes[i]
+		}
+		note.san = chess.Encoder.Encode(chess.AlgebraicNotation{}, positions[i], move)
+		moves[i] = &note
+	}
+	start := positions[0]
+	tokens := pgnMoveTokens(nil, moves, fullMoveNumber(start), start.Turn())
+	if len(notes) > len(moves) {
+		for _, comment := range notes[len(moves)].before {
+			tokens = pgnCommentTokens(tokens, comment)
+		}
+	}
+	tokens = append(tokens, game.Outcome().String())
+
+	line := 0
+	for i, token := range tokens {
+		first := token // Comments keep their line breaks.
+		if n := strings.IndexByte(token, '\n'); n >= 0 {
+			first = token[:n]
+		}
+		if i > 0 {
+			// A line starting with "%" would be escaped, keep such words on the line.
+			if (line+1+len(first) > gPGNLineWidth && token[0] != '%') || strings.HasPrefix(tokens[i-1], ";") {
+				sb.WriteString("\n")
+				line = 0
+			} else {
+				sb.WriteString(" ")
+				line++
+			}
+		}
+		sb.WriteString(token)
+		if n := strings.LastIndexByte(token, '\n'); n >= 0 {
+			line = len(token) - n - 1
+		} else {
+			line += len(token)
+		}
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// Append a comment in braces as written, or to the end of the line if it holds a brace.
+func pgnCommentTokens(tokens []string, comment string) []string {
+	if strings.Contains(comment, "}") {
+		return append(tokens, ";"+comment)
+	}
+	return append(tokens, "{"+comment+"}")
+}
+
+// Append the tokens of a line of moves starting at the move number and
+// side to move, with their NAGs, comments and variations.
+func pgnMoveTokens(tokens []string, moves []*pgnMove, moveNum int, turn chess.Color) []string {
+	interrupted := true // Black's move needs its number after a comment or variation.
+	for _, move := range moves {
+		for _, comment := range move.before {
+			tokens = pgnCommentTokens(tokens, comment)
+		}
+		if turn == chess.White {
+			tokens = append(tokens, strconv.Itoa(moveNum)+".")
+		} else if interrupted || len(move.before) > 0 {
+			tokens = append(tokens, strconv.Itoa(moveNum)+"...")
+		}
+		tokens = append(tokens, move.san)
+		interrupted = false
+
+		for i, note := range move.after {
+			switch {
+			case note.variation != nil:
+				first := len(tokens)
+				tokens = pgnMoveTokens(tokens, note.variation, moveNum, turn)
+				tokens[first] = "(" + tokens[first]
+				if last := len(tokens) - 1; strings.HasPrefix(tokens[last], ";") {
+					tokens = append(tokens, ")")
+				} else {
+					tokens[last] += ")"
+				}
+				interrupted = true
+			case note.comment != "":
+				tokens = pgnCommentTokens(tokens, note.comment)
+				interrupted = true
+			case note.suffix && i == 0 && nagSuffix(note.nag) != "":
+				tokens[len(tokens)-1] += nagSuffix(note.nag)
+			default:
+				tokens = append(tokens, "$"+strconv.Itoa(note.nag))
+			}
+		}
+
+		if turn == chess.Black {
+			moveNum++
+		}
+		turn = turn.Other()
+	}
+	return tokens
+}
+
+// Full move number of the position, as counted in its FEN.
+func fullMoveNumber(pos *chess.Position) int {
+	fields := strings.Fields(pos.String())
+	n, _ := strconv.Atoi(fields[len(fields)-1])
+	return n
+}
@@ -0,0 +1,247 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Parse the first game of the PGN text and encode it again.
+func roundTripPGN(t *testing.T, text string) string {
+	t.Helper()
+	games, err := parsePGN(text)
+	if err != nil {
+		t.Fatalf("parsePGN failed: %v", err)
+	}
+	game, notes, err := games[0].game()
+	if err != nil {
+		t.Fatalf("replaying the game failed: %v", err)
+	}
+	return encodeAnnotatedPGN(game, notes)
+}
+
+func TestPGNRoundTrip(t *testing.T) {
+	tests := []struct {
+		name, pgn string
+	}{
+		{"moves", `[Event "Casual game"]
+[White "Human"]
+[Black "stockfish"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 1-0
+`},
+		{"comments and NAGs", `[Event "Casual game"]
+
+{Opening comment} 1. e4 {[%clk 0:04:58]} 1... e5 $1 2. Nf3 $14 {White is
+better} 2... Nc6 *
+`},
+		{"suffixes", `[Event "Casual game"]
+
+1. e4! e5?! 2. Qh5?? Nc6!? 3. Bc4 Nf6?? 4. Qxf7# 1-0
+`},
+		{"nested variations", `[Event "Casual game"]
+
+1. e4 e5 (1... c5 2. Nf3 (2. c3 d5) 2... d6) 2. Nf3 (2. f4 exf4 3. Nf3) 2...
+Nc6 *
+`},
+		{"variation comments", `[Event "Casual game"]
+
+1. d4 d5 2. c4 ({Or} 2. Nf3 Nf6 {quiet}) 2... e6 *
+`},
+		{"brace in comment", `[Event "Casual game"]
+
+1. e4 ;a } brace
+1... e5 *
+`},
+		{"escaped tag", `[Event "The \"big\" game \\ 1"]
+
+1. e4 *
+`},
+		{"set up position", `[Event "Casual game"]
+[SetUp "1"]
+[FEN "4k3/8/8/8/8/8/4P3/4K3 b - - 0 12"]
+
+12... Kd7 13. e4 (13. e3 Ke6) 13... Ke6 *
+`},
+		{"comments as written", `[Event "Casual game"]
+
+1. e4 {  two  spaces,
+   an indented line
+%and an escape-like line } 1... e5 *
+`},
+		{"comments without moves", `[Event "Casual game"]
+
+{Nothing played} *
+`},
+	}
+	for _, test := range tests {
+		if got := roundTripPGN(t, test.pgn); got != test.pgn {
+			t.Errorf("%s: round trip changed the game\ngot:\n%s\nwant:\n%s", test.name, got, test.pgn)
+		}
+	}
+}
+
+func TestPGNImportFormat(t *testing.T) {
+	tests := []struct {
+		name, pgn, want string
+	}{
+		{"castling with zeros", "1. e4 e5 2. Nf3 Nc6 3. Bc4 Bc5 4. 0-0 *", "4. O-O *"},
+		{"loose move numbers", "1.e4 1...e5 2.Nf3 *", "1. e4 e5 2. Nf3 *"},
+		{"missing check", "1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7 1-0", "4. Qxf7# 1-0"},
+		{"escaped line", "1. e4\n% ignored line\ne5 *", "1. e4 e5 *"},
+		{"rest of line comment", "1. e4 ; king's pawn\ne5 *", "1. e4 {king's pawn} 1... e5 *"},
+		{"variation check", "1. e4 e5 2. Qh5 (2. Bc4 Nc6 3. Qh5 Nf6 4. Qxf7) *", "(2. Bc4 Nc6 3. Qh5 Nf6 4. Qxf7#) *"},
+	}
+	for _, test := range tests {
+		if got := roundTripPGN(t, test.pgn); !strings.Contains(got, test.want) {
+			t.Errorf("%s: got\n%s\nwant it to contain %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestPGNErrors(t *testing.T) {
+	tests := []struct {
+		name, pgn, err string
+	}{
+		{"unterminated comment", "1. e4 {oops", "line 1: unterminated comment"},
+		{"unterminated variation", "1. e4 (1. d4 *", "result * inside a variation"},
+		{"unexpected close", "1. e4 ) *", "unexpected )"},
+		{"NAG before moves", "$1 1. e4 *", "NAG $1 before any move"},
+		{"bad suffix", "1. e4!!! *", "invalid move annotation"},
+		{"line number", "[Event \"x\"]\n\n1. e4\n{oops", "line 4: unterminated comment"},
+	}
+	for _, test := range tests {
+		_, err := parsePGN(test.pgn)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: parsePGN error %v, want %q", test.name, err, test.err)
+		}
+	}
+}
+
+func TestPGNIllegalMoves(t *testing.T) {
+	tests := []struct {
+		name, pgn, err string
+	}{
+		{"main line", "1. e4 e5 2. Ke3 *", ""},
+		{"variation", "1. e4 e5 (1... e4) 2. Nf3 *", "illegal move e4 at move 1 of a variation"},
+		{"variation continued", "1. e4 e5 (1... c5 2. Nf6) *", "illegal move Nf6 at move 2 of a variation"},
+		{"nested variation", "1. e4 e5 (1... c5 2. Nf3 (2. Bb6)) *", "illegal move Bb6 at move 2 of a variation"},
+		{"variation of the wrong side", "1. e4 (1... d5) e5 *", "illegal move d5 at move 1 of a variation"},
+	}
+	for _, test := range tests {
+		games, err := parsePGN(test.pgn)
+		if err != nil {
+			t.Fatalf("%s: parsePGN failed: %v", test.name, err)
+		}
+		_, _, err = games[0].game()
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: replay error %v, want %q", test.name, err, test.err)
+		}
+	}
+}
+
+func TestParsePGNGames(t *testing.T) {
+	text := `[Event "First"]
+
+1. e4 e5 1/2-1/2
+
+[Event "Second"]
+
+1. d4 d5
+
+[Event "Third"]
+
+1. c4 *
+`
+	games, err := parsePGN(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ event, result string }{{"First", "1/2-1/2"}, {"Second", ""}, {"Third", "*"}}
+	if len(games) != len(want) {
+		t.Fatalf("parsePGN found %d games, want %d", len(games), len(want))
+	}
+	for i, g := range games {
+		if g.tags[0].Value != want[i].event || g.result != want[i].result {
+			t.Errorf("game %d is %s with result %q, want %s with %q", i, g.tags[0].Value, g.result, want[i].event, want[i].result)
+		}
+	}
+}
+
+func TestSetCommand(t *testing.T) {
+	tests := []struct {
+		name  string
+		after []pgnNote
+		want  []pgnNote
+	}{
+		{"new", nil, []pgnNote{{comment: "[%clk 0:01:00]"}}},
+		{"after suffix", []pgnNote{{nag: 1, suffix: true}}, []pgnNote{{nag: 1, suffix: true}, {comment: "[%clk 0:01:00]"}}},
+		{"appended", []pgnNote{{comment: "good"}}, []pgnNote{{comment: "good [%clk 0:01:00]"}}},
+		{"replaced in place", []pgnNote{{comment: "[%clk 0:02:00] good"}}, []pgnNote{{comment: "[%clk 0:01:00] good"}}},
+	}
+	for _, test := range tests {
+		got := setCommand(pgnMove{san: "e4", after: test.after}, "clk", "[%clk 0:01:00]").after
+		if len(got) != len(test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i].nag != test.want[i].nag || got[i].suffix != test.want[i].suffix || got[i].comment != test.want[i].comment {
+				t.Errorf("%s: got %+v, want %+v", test.name, got, test.want)
+			}
+		}
+	}
+
+	kept := setCommand(pgnMove{after: []pgnNote{{comment: "[%clk 0:02:00]  good\nline"}}}, "clk", "[%clk 0:01:00]")
+	if got := kept.after[0].comment; got != "[%clk 0:01:00]  good\nline" {
+		t.Errorf("comment after setting the clock = %q, want the rest as written", got)
+	}
+	if got := setCommand(kept, "clk", "").after[0].comment; got != "good\nline" {
+		t.Errorf("comment after removing the clock = %q, want the rest as written", got)
+	}
+
+	removed := setCommand(pgnMove{after: []pgnNote{{comment: "[%clk 0:02:00]"}}}, "clk", "")
+	if len(removed.after) != 0 {
+		t.Errorf("removing the only command left %+v", removed.after)
+	}
+}
+
+func TestSavePGNSetUp(t *testing.T) {
+	fen := "4k3/8/8/8/8/8/4P3/4K3 w - - 0 12"
+	game := gameFromFEN(t, fen)
+	if err := game.MoveStr("e4"); err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(t.TempDir(), "game.pgn")
+	if err := savePGN(game, filename); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, _, err := readPGN(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start := loaded.Positions()[0].String(); start != fen {
+		t.Errorf("saved game starts from %q, want %q", start, fen)
+	}
+	if got := gameLAN(loaded); got != "e2e4" {
+		t.Errorf("saved game moves = %q, want e2e4", got)
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"github.com/abperiasamy/chess"
+	"github.com/abperiasamy/pinata/uci"
+)
+
+// Let the engine think on the human's expected reply while the human is at the prompt.
+func startPondering(engine *uci.Engine, game *chess.Game, ponderMove string) {
+	gPonderMove = ""
+	if !gPonder || ponderMove == "" || game.Outcome() != chess.NoOutcome {
+		return
+	}
+
+	// Engines may suggest garbage when they have no reply in mind.
+	valid := false
+	for _, move := range game.ValidMoves() {
+		if move.String() == ponderMove {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return
+	}
+
+	engine.SetPosition(game.FEN(), ponderMove)
+	if engine.Ponder(engineLimits(), uci.HighestDepthOnly) == nil {
+		gPonderMove = ponderMove
+	}
+}
+
+// Search the engine's reply to the human's move. The ponder search carries
+// on if the human played the expected move and is abandoned otherwise.
+func ponderSearch(engine *uci.Engine, game *chess.Game) (*uci.Results, error) {
+	expected := gPonderMove
+	gPonderMove = ""
+
+	moves := game.Moves()
+	if engine.Pondering() && len(moves) > 0 && moves[len(moves)-1].String() == expected {
+		return engine.PonderHit()
+	}
+
+	engine.SetFEN(game.FEN()) // Also stops pondering on the wrong move.
+	return engine.Go(engineLimits(), uci.HighestDepthOnly)
+}
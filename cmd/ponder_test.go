@@ -0,0 +1,58 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"testing"
+)
+
+func TestPonderSearch(t *testing.T) {
+	engine := startFakeEngine(t)
+	saved := gPonder
+	t.Cleanup(func() { gPonder, gPonderMove = saved, "" })
+
+	tests := []struct {
+		name           string
+		ponder         bool
+		expected, move string // Reply expected by the engine and the one played.
+		pondering      bool
+		best           string
+	}{
+		{"hit", true, "a7a6", "a7a6", true, "a2a3"},
+		{"miss", true, "a7a6", "h7h6", true, "a2a3"},
+		{"off", false, "a7a6", "a7a6", false, "a2a3"},
+		{"illegal reply", true, "e2e4", "a7a6", false, "a2a3"},
+		{"no reply", true, "", "a7a6", false, "a2a3"},
+	}
+	for _, test := range tests {
+		gPonder = test.ponder
+		game := playLAN(t, "e2e4")
+		startPondering(engine, game, test.expected)
+		if want := map[bool]string{true: test.expected}[test.pondering]; engine.Pondering() != test.pondering || gPonderMove != want {
+			t.Errorf("%s: pondering %v on %q, want %v", test.name, engine.Pondering(), gPonderMove, test.pondering)
+		}
+
+		game = playLAN(t, "e2e4 "+test.move)
+		results, err := ponderSearch(engine, game)
+		if err != nil || results.BestMove != test.best {
+			t.Errorf("%s: ponderSearch = %+v, %v, want %s", test.name, results, err, test.best)
+		}
+		if engine.Pondering() || gPonderMove != "" {
+			t.Errorf("%s: still pondering on %q after the search", test.name, gPonderMove)
+		}
+	}
+}
@@ -18,6 +18,8 @@ package cmd
 
 import (
 	"strconv"
+
+	"github.com/abperiasamy/chess"
 )
 
 const (
@@ -55,32 +57,61 @@ func blackPrompt() string {
 	return gBlackPrompt + " " + strconv.Itoa(gMoveCount) + " "
 }
 
+// Remaining time of the given side, empty when playing without a clock.
+func clockPrompt(color chess.Color) string {
+	if gClock == nil {
+		return ""
+	}
+	return formatClock(gClock.timeLeft(color)) + " "
+}
+
 // Engine's shell prompt
 func enginePrompt() string {
+	clock := clockPrompt(humanColor().Other())
 	if gNoColor {
 		if gHumanIsBlack {
-			return whitePrompt() + ":] "
+			return whitePrompt() + clock + ":] "
 		}
-		return blackPrompt() + ":] "
+		return blackPrompt() + clock + ":] "
 	} else {
 		if gHumanIsBlack {
-			return whitePrompt() + "🤖 "
+			return whitePrompt() + clock + "🤖 "
 		}
-		return blackPrompt() + "🤖 "
+		return blackPrompt() + clock + "🤖 "
 	}
 }
 
 // Human's shell prompt
 func humanPrompt() string {
+	if gHumanVsHuman {
+		return duelPrompt()
+	}
+
+	clock := clockPrompt(humanColor())
 	if gNoColor {
 		if gHumanIsBlack {
-			return blackPrompt() + ":) "
+			return blackPrompt() + clock + ":) "
 		}
-		return whitePrompt() + ":) "
+		return whitePrompt() + clock + ":) "
 	} else {
 		if gHumanIsBlack {
-			return blackPrompt() + "🙇 "
+			return blackPrompt() + clock + "🙇 "
 		}
-		return whitePrompt() + "🙇 "
+		return whitePrompt() + clock + "🙇 "
+	}
+}
+
+// Shell prompt of the side to move in a human vs human game
+func duelPrompt() string {
+	turn := gGame.Position().Turn()
+	prompt := whitePrompt()
+	if turn == chess.Black {
+		prompt = blackPrompt()
+	}
+	prompt += clockPrompt(turn)
+
+	if gNoColor {
+		return prompt + ":) "
 	}
+	return prompt + "🙇 "
 }
@@ -0,0 +1,67 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// resumeCmd resumes an unfinished game from its journal
+var resumeCmd = &cobra.Command{
+	Use:   "resume [journal]",
+	Short: "Resume an unfinished game, the most recent one unless named.",
+	Long: `Every game is journaled move by move, so a game cut short by a crash or a
+closed terminal can be resumed with its side, engine and settings.`,
+	Args: cobra.MaximumNArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		onStart()
+		journals := unfinishedJournals()
+		filename := ""
+		if len(args) == 1 {
+			filename = findJournal(args[0])
+		} else if len(journals) > 0 {
+			filename = journals[0]
+			if len(journals) > 1 { // Name the others for a later resume.
+				var names []string
+				for _, j := range journals[1:] {
+					names = append(names, strings.TrimSuffix(filepath.Base(j), ".journal"))
+				}
+				fmt.Println("Other unfinished games:", strings.Join(names, ", "))
+			}
+		} else {
+			fmt.Println("No unfinished games to resume.")
+			return
+		}
+
+		if err := resumeGame(filename); err != nil {
+			fmt.Println("Unable to resume", gConsole.Bold(gConsole.Red(filename)).String()+",", err)
+			os.Exit(1)
+		}
+		shell()
+		onStop()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(resumeCmd)
+}
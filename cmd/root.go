@@ -18,11 +18,14 @@ package cmd
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"runtime"
+	"strings"
 
 	"github.com/abperiasamy/chess"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -54,6 +57,15 @@ func onStart() {
 	chess.ConsoleColor = !gNoColor
 	chess.ConsoleUnicode = !gNoColor // also disable unicode printing
 
+	// Read the lichess.org access-token unless given on the command line.
+	if gLichessAuthTok == "" && gLichessTokenFile != "" {
+		token, err := ioutil.ReadFile(gLichessTokenFile)
+		if err != nil {
+			fmt.Println("Unable to read the lichess.org access-token,", err)
+			os.Exit(1)
+		}
+		gLichessAuthTok = strings.TrimSpace(string(token))
+	}
 }
 
 // Perform post initialization routines right after the game ends.
@@ -69,55 +81,56 @@ func Execute() {
 }
 
 // Load config file and register flags.
+// Flags of the root command, for the code its Run reaches, which cannot
+// refer to rootCmd itself.
+var gRootFlags *pflag.FlagSet
+
 func init() {
+	gRootFlags = rootCmd.PersistentFlags()
 	// fmt.Print("\033[?25l") // Hide cursor
-	// Initialize config and ENV first. Command-line flags may override these settings.
-	// cobra.OnInitialize(initConfig)
+	// Initialize config first. Command-line flags override these settings.
+	cobra.OnInitialize(initConfig)
 
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 
-	// rootCmd.PersistentFlags().StringVarP(&gCfgFile, "config", "c", "pinata.toml", "config file")
+	rootCmd.PersistentFlags().StringVarP(&gCfgFile, "config", "c", "", "config file (default pinata/config.json in your user config directory, like ~/.config)")
 	rootCmd.PersistentFlags().StringVarP(&gEngineBinary, "engine", "e", "stockfish", "path to UCI compatible chess engine executable")
-	rootCmd.PersistentFlags().StringVarP(&gGamePath, "file", "f", "", "load game from a PGN file")
+	rootCmd.PersistentFlags().StringVar(&gBookPath, "book", "", "play the engine's opening moves from a Polyglot opening book")
+	rootCmd.PersistentFlags().StringVarP(&gGamePath, "file", "f", "", "load game from a PGN file or the library by its id")
+	rootCmd.PersistentFlags().StringVar(&gLibraryDir, "library", "", "directory of saved games (default games next to the config file)")
 	rootCmd.PersistentFlags().StringVarP(&gLichessAuthTok, "analyze", "a", "", "lichess.org API access-token to analyze the game")
+	rootCmd.PersistentFlags().StringVar(&gLichessTokenFile, "analyze-token-file", "", "read the lichess.org API access-token from this file")
 	rootCmd.PersistentFlags().BoolVarP(&gHumanIsBlack, "black", "b", false, "choose the black side")
 	rootCmd.PersistentFlags().BoolVarP(&gVisual, "visual", "v", false, "cheat blindfold")
+	rootCmd.PersistentFlags().IntVar(&gRevealUntil, "reveal-until", 0, "show the board until this move")
+	rootCmd.PersistentFlags().IntVar(&gRevealEvery, "reveal-every", 0, "show the board every n moves")
+	rootCmd.PersistentFlags().IntVar(&gMemCheckEvery, "memcheck-every", 0, "ask you to recall the position every n moves")
 	if runtime.GOOS == "windows" { // disable color and unicode support on Windows by default
 		rootCmd.PersistentFlags().BoolVar(&gNoColor, "color", true, "disable colors")
 	} else {
 		rootCmd.PersistentFlags().BoolVar(&gNoColor, "no-color", false, "disable colors")
 	}
+	rootCmd.PersistentFlags().StringVar(&gKeyBindings, "keys", "emacs", "key bindings of the prompt, vi or emacs")
+	rootCmd.PersistentFlags().IntVar(&gHistorySize, "history-size", 500, "commands kept in the history file, 0 to keep no history")
+	rootCmd.PersistentFlags().BoolVar(&gHistoryMoves, "history-moves", false, "also keep your moves in the command history")
 	rootCmd.PersistentFlags().BoolVarP(&gLightBg, "light", "l", false, "invert the colors for lighter console background")
 	rootCmd.PersistentFlags().IntVarP(&gEngineDepth, "depth", "d", 10, "engine search depth")
+	rootCmd.PersistentFlags().IntVar(&gEngineMoveTime, "movetime", 0, "engine search time per move in milliseconds")
+	rootCmd.PersistentFlags().IntVar(&gEngineNodes, "nodes", 0, "engine search node count per move")
+	rootCmd.PersistentFlags().IntVar(&gEngineElo, "elo", 0, "limit engine strength to this Elo rating")
+	rootCmd.PersistentFlags().IntVar(&gEngineSkill, "skill", -1, "limit engine strength to this skill level (0-20 on stockfish)")
+	rootCmd.PersistentFlags().BoolVar(&gHumanVsHuman, "human-vs-human", false, "pass-and-play against another human, without an engine")
+	rootCmd.PersistentFlags().StringVar(&gWhiteName, "white-name", "Human", "name of the white player in a human vs human game")
+	rootCmd.PersistentFlags().StringVar(&gBlackName, "black-name", "Human", "name of the black player in a human vs human game")
+	rootCmd.PersistentFlags().BoolVar(&gPonder, "ponder", false, "let the engine think during your time")
+	rootCmd.PersistentFlags().StringVar(&gSpeak, "speak", "", "speak the engine's moves with this text-to-speech `command`, "+defaultSpeechCommand()+" if omitted")
+	rootCmd.PersistentFlags().Lookup("speak").NoOptDefVal = defaultSpeechCommand()
+	rootCmd.PersistentFlags().BoolVar(&gSpeakHuman, "speak-human", false, "also speak your own moves")
+	rootCmd.PersistentFlags().StringVar(&gNotation, "notation", "san", "move notation: san, lan (uci), figurine, descriptive or localized de, fr, es, it, nl, pt")
+	rootCmd.PersistentFlags().StringVarP(&gTimeControl, "time", "t", "", "time control in minutes+increment seconds (5+3) or minutes d delay seconds (5d2)")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
 }
-
-/*
-// initConfig reads in config file and ENV variables if set.
-func initConfig() {
-	if gCfgFile != "" {
-		// Use config file from the flag.
-		viper.SetConfigFile(gCfgFile)
-	} else {
-			// Find home directory.
-			home, err := homedir.Dir()
-			if err != nil {
-				fmt.Println(err)
-				os.Exit(1)
-			}
-
-		// Search config in home directory with name ".pinata" (without extension).
-		// viper.AddConfigPath(home) // search first in the home dir.
-		viper.AddConfigPath(".") // search in the current dir.
-
-		// Default to TOML format, though viper supports a variety of standard formats.
-		viper.SetConfigName(gCfgFile)
-	}
-
-	viper.AutomaticEnv() // read in environment variables that match
-}
-*/
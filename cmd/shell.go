@@ -19,12 +19,13 @@ package cmd
 import (
 	"fmt"
 	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/abperiasamy/chess"
+	"github.com/abperiasamy/pinata/uci"
 	"github.com/chzyer/readline"
 )
 
@@ -40,8 +41,8 @@ func filterInput(r rune) (rune, bool) {
 	return r, true
 }
 
-// Readline file listing
-func completeLoad(path string) func(string) []string {
+// Readline PGN file listing
+func completeSave(path string) func(string) []string {
 	return func(line string) []string {
 		names := make([]string, 0)
 		files, _ := ioutil.ReadDir(path)
@@ -54,15 +55,26 @@ func completeLoad(path string) func(string) []string {
 	}
 }
 
-// Execute adds all child commands to the root command and sets flags appropriately.
-// This is called by main.main(). It only needs to happen once to the rootCmd.
-func shell() {
+// Readline file and library game listing, most recent games first.
+func completeLoad(path string) func(string) []string {
+	return func(line string) []string {
+		names := completeSave(path)(line)
+		games := libraryGames()
+		for i := len(games) - 1; i >= 0; i-- {
+			names = append(names, gameID(games[i]))
+		}
+		return names
+	}
+}
+
+// Start a new game, from a PGN file if asked.
+func startGame() {
 	// Initialize a new game and save it in global gGame.
 	gGame = chess.NewGame(chess.UseNotation(chess.AlgebraicNotation{}))
 
 	// Load game from PGN.
 	if gGamePath != "" {
-		filename := gGamePath
+		filename := findGameFile(gGamePath)
 		// Append default name to dir if empty.
 		fInfo, err := os.Stat(filename)
 		if err == nil && fInfo.IsDir() {
@@ -79,28 +91,73 @@ func shell() {
 			// fmt.Println("Unable to open " + gConsole.Bold(gConsole.Red(filename)).String() + ".")
 			os.Exit(1)
 		}
+		if inLibrary(filename) { // Continue the library game in place.
+			gLibraryFile = filename
+		}
 
 		// Check to see if the game already ended.
 		if isGameOver(gGame) {
 			drawBoard(gGame)
 			os.Exit(0)
 		}
+		syncMoveCount(gGame)
 	}
+}
 
-	eng, err := newEngine(gEngineBinary)
-	if err != nil {
-		log.Fatal(err)
+// Execute adds all child commands to the root command and sets flags appropriately.
+// This is called by main.main(). It only needs to happen once to the rootCmd.
+func shell() {
+	if gKeyBindings != "vi" && gKeyBindings != "emacs" {
+		fmt.Println("Allowed key bindings are", gConsole.Bold(gConsole.Yellow("[vi|emacs]")))
+		os.Exit(1)
 	}
-	defer eng.Close()
-	eng.SendOption("Threads", "8")
 
 	completer := readline.NewPrefixCompleter(
 		readline.PcItemDynamic(validMovesConstructor()),
 		readline.PcItem("resign"),
+		readline.PcItem("/draw"),
+		readline.PcItem("/undo"),
+		readline.PcItem("/redo"),
+		readline.PcItem("/hint"),
+		readline.PcItem("/limit",
+			readline.PcItem("depth"),
+			readline.PcItem("movetime"),
+			readline.PcItem("nodes"),
+		),
+		readline.PcItem("/level",
+			readline.PcItem("elo"),
+			readline.PcItem("skill"),
+			readline.PcItem("off"),
+		),
+		readline.PcItem("/opening"),
+		readline.PcItem("/piece"),
+		readline.PcItem("/where",
+			readline.PcItem("white"),
+			readline.PcItem("black"),
+		),
+		readline.PcItem("/list",
+			readline.PcItem("white"),
+			readline.PcItem("black"),
+		),
+		readline.PcItem("/moves"),
+		readline.PcItem("/notation",
+			readline.PcItem("san"),
+			readline.PcItem("lan"),
+			readline.PcItem("figurine"),
+			readline.PcItem("descriptive"),
+			readline.PcItem("de"),
+			readline.PcItem("fr"),
+			readline.PcItem("es"),
+			readline.PcItem("it"),
+			readline.PcItem("nl"),
+			readline.PcItem("pt"),
+		),
 		readline.PcItem("/fen"),
-		readline.PcItem("/save", readline.PcItem(gGameFilename)),
+		readline.PcItem("/save", readline.PcItemDynamic(completeSave("."))),
 		readline.PcItem("/load", readline.PcItemDynamic(completeLoad("."))),
 		readline.PcItem("/visual"),
+		readline.PcItem("/glimpse"),
+		readline.PcItem("/memcheck"),
 		readline.PcItem("/quit"),
 		readline.PcItem("/keys",
 			readline.PcItem("vi"),
@@ -109,61 +166,238 @@ func shell() {
 
 	l, err := readline.NewEx(&readline.Config{
 		// Prompt: "\033[31m»\033[0m ",
-		// HistoryFile:     "/tmp/readline.tmp",
-		AutoComplete:        completer,
-		InterruptPrompt:     "/quit",
-		EOFPrompt:           "\n",
-		HistorySearchFold:   true,
-		FuncFilterInputRune: filterInput,
+		HistoryFile:            historyPath(),
+		HistoryLimit:           historyLimit(),
+		DisableAutoSaveHistory: true, // Saved by saveHistory, leaving out the moves.
+		AutoComplete:           completer,
+		VimMode:                gKeyBindings == "vi",
+		InterruptPrompt:        "/quit",
+		EOFPrompt:              "\n",
+		HistorySearchFold:      true,
+		FuncFilterInputRune:    filterInput,
 	})
 	if err != nil {
 		panic(err)
 	}
 	defer l.Close()
 
-	gameStarted := false
+	// Offer to resume a game cut short, unless told which game to play.
+	if gJournal == nil && gGamePath == "" {
+		offerResume(l)
+	}
+
+	notation, err := parseNotation(gNotation)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	gNotation = notation
+
+	resumed := gJournal != nil
+	if resumed && isGameOver(gGame) { // The game ended as pinata went down.
+		gJournal.remove()
+		os.Exit(0)
+	} else if !resumed {
+		startGame()
+		gJournal = newJournal()
+	}
+
+	// Start the clocks, unless the loaded game already brought its own.
+	if gTimeControl != "" && gClock == nil {
+		clock, err := newChessClock(gTimeControl)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		gClock = clock
+	}
+
+	// Open the engine's opening book.
+	if gBookPath != "" && !gHumanVsHuman {
+		book, err := loadBook(gBookPath)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		gBook = book
+	}
+
+	// Humans playing each other need no engine.
+	var eng *uci.Engine
+	if !gHumanVsHuman {
+		eng = startEngine()
+	}
+	defer func() {
+		if eng != nil {
+			eng.Close()
+		}
+	}()
+
+	gameStarted := resumed // A resumed game has moves not saved yet.
 
-	if humanColor() == chess.Black {
+	if !gHumanVsHuman && gGame.Position().Turn() != humanColor() {
 		err = engineMoveFirst(eng, gGame)
 		if err != nil {
-			fmt.Errorf("Engine Failure: ", err)
+			fmt.Println("Engine Failure:", err)
 			os.Exit(1)
 		}
 		gameStarted = true
 	}
 
 	for {
+		syncMoveCount(gGame)
+		if gVisual {
+			gPlayedVisual = true
+		}
+		gJournal.sync(gGame)
+		announceOpening(gGame)
+		if memCheckDue(gGame) {
+			memCheck(l, gGame)
+		}
 		l.SetPrompt(humanPrompt())
+		turn := gGame.Position().Turn() // Always a human's turn at the prompt.
+
+		// Wake up the prompt when the human's flag falls.
+		var flagTimer *time.Timer
+		if gClock != nil {
+			if gClock.running != turn {
+				gClock.start(turn)
+			}
+			flagTimer = time.AfterFunc(gClock.timeLeft(turn), func() {
+				l.WriteStdin([]byte("\n"))
+			})
+		}
+
 		cmd, err := l.Readline()
+		if flagTimer != nil {
+			flagTimer.Stop()
+		}
 		if err == readline.ErrInterrupt {
 			cmd = "/quit"
 		}
 		cmd = strings.TrimSpace(cmd)
+		saveHistory(l, cmd)
+
+		if gClock != nil && gClock.timeLeft(turn) <= 0 {
+			gClock.stop()
+			flagFall(gGame, turn)
+			isGameOver(gGame)
+			saveGame(gGame)
+			goto end
+		}
+
 		switch {
 		case cmd == "": // no input, do nothing.
 
 		case cmd == "resign":
-			gGame.Resign(humanColor())
+			gGame.Resign(turn)
 			isGameOver(gGame) // Game is over, but print the status.
 
 			// Save the game.
-			if savePGN(gGame, gGameFilename) == nil { // Success
-				fmt.Println("Game saved to", gConsole.Bold(gConsole.Red(gGameFilename)))
+			saveGame(gGame)
+
+			goto end
+
+		case cmd == "/draw":
+			if !drawGame(eng, l, gGame) {
+				continue
 			}
+			isGameOver(gGame) // Game is over, but print the status.
+
+			// Save the game.
+			saveGame(gGame)
 
 			goto end
 
+		case strings.HasPrefix(cmd, "/undo"):
+			n, err := takebackCount(cmd)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			undoMoves(eng, n)
+
+		case strings.HasPrefix(cmd, "/redo"):
+			n, err := takebackCount(cmd)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			redoMoves(eng, n)
+
+		case gHumanVsHuman && (strings.HasPrefix(cmd, "/hint") || strings.HasPrefix(cmd, "/limit") || strings.HasPrefix(cmd, "/level")):
+			fmt.Println("There is no engine in a human vs human game.")
+
+		case strings.HasPrefix(cmd, "/hint"):
+			n, err := hintCount(cmd)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			showHints(eng, gGame, n)
+
+		case strings.HasPrefix(cmd, "/limit"):
+			args := strings.Fields(cmd)[1:]
+			if len(args) > 0 {
+				limits, err := parseSearchLimits(args)
+				if err != nil {
+					fmt.Println(err)
+					continue
+				}
+				gSearchLimits = limits
+			}
+			showSearchLimits()
+
+		case strings.HasPrefix(cmd, "/level"):
+			args := strings.Fields(cmd)[1:]
+			if len(args) > 0 {
+				elo, skill, err := parseLevel(args)
+				if err == nil {
+					err = setLevel(eng, elo, skill)
+				}
+				if err != nil {
+					fmt.Println(err)
+					continue
+				}
+			}
+			showLevel()
+
+		case cmd == "/opening":
+			showOpening(gGame)
+
+		case strings.HasPrefix(cmd, "/piece"):
+			showPiece(gGame, strings.Fields(cmd)[1:])
+
+		case strings.HasPrefix(cmd, "/where"):
+			showWhere(gGame, strings.Fields(cmd)[1:])
+
+		case strings.HasPrefix(cmd, "/list"):
+			showList(gGame, strings.Fields(cmd)[1:])
+
+		case cmd == "/moves":
+			showMoves(gGame)
+
+		case strings.HasPrefix(cmd, "/notation"):
+			setNotation(strings.Fields(cmd)[1:])
+
 		case strings.HasPrefix(cmd, "/fen"):
 			cmd := strings.SplitN(cmd, " ", 2)
 			if len(cmd) > 1 {
 				fenStr := cmd[1]
-				eng.SetFEN(fenStr)
+				if eng != nil {
+					eng.SetFEN(fenStr)
+				}
 				fen, err := chess.FEN(fenStr)
 				if err != nil {
 					fmt.Println("Not a valid FEN.")
 					continue
 				}
 				gGame = chess.NewGame(fen)
+				gRedoMoves = nil
+				gTermination = ""
+				gLibraryFile = "" // A new game.
+				gMoveNotes = nil
+				syncMoveCount(gGame)
 				if isGameOver(gGame) { // No more moves to play.
 					goto end
 				}
@@ -173,9 +407,12 @@ func shell() {
 
 		case strings.HasPrefix(cmd, "/load"):
 			cmd := strings.SplitN(cmd, " ", 2)
-			filename := gGameFilename
+			filename := lastLibraryGame()
 			if len(cmd) == 2 {
-				filename = cmd[1]
+				filename = findGameFile(cmd[1])
+			} else if filename == "" {
+				fmt.Println("No other games in the library, name the game to load.")
+				continue
 			}
 
 			// Append default name to dir if empty.
@@ -194,18 +431,29 @@ func shell() {
 
 			g := loadPGN(filename)
 			if g != nil { // Success
-				gGame = g              // Overwrite the current game.
+				gGame = g // Overwrite the current game.
+				gLibraryFile = ""
+				if inLibrary(filename) { // Continue the library game in place.
+					gLibraryFile = filename
+				}
 				if isGameOver(gGame) { // No more moves to play.
 					goto end
 				}
+				syncMoveCount(gGame)
+				if !gHumanVsHuman && eng == nil { // Switched from a human vs human game.
+					eng = startEngine()
+				} else if eng != nil {
+					sendLevel(eng, gEngineElo, gEngineSkill) // Level of the loaded game.
+				}
 			}
 
 		case strings.HasPrefix(cmd, "/save"):
 			cmd := strings.SplitN(cmd, " ", 2)
-			filename := gGameFilename
-			if len(cmd) == 2 {
-				filename = strings.TrimSpace(cmd[1])
+			if len(cmd) == 1 || strings.TrimSpace(cmd[1]) == "" { // Save to the library.
+				saveGame(gGame)
+				continue
 			}
+			filename := strings.TrimSpace(cmd[1])
 
 			// Append default name to dir if empty.
 			fInfo, err := os.Stat(filename)
@@ -233,6 +481,17 @@ func shell() {
 			}
 			continue
 
+		case cmd == "/memcheck":
+			memCheck(l, gGame)
+
+		case strings.HasPrefix(cmd, "/glimpse"):
+			d, err := glimpseDuration(cmd)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			glimpse(gGame, d)
+
 		case strings.HasPrefix(cmd, "/keys"):
 			cmd := strings.SplitN(cmd, " ", 2)
 			if len(cmd) > 1 {
@@ -256,25 +515,27 @@ func shell() {
 		case cmd == "/quit":
 
 			// Save the game.
-			if gameStarted && savePGN(gGame, gGameFilename) == nil { // Success
-				fmt.Println("Game saved to", gConsole.Bold(gConsole.Red(gGameFilename)))
+			if gameStarted {
+				saveGame(gGame)
 			}
 
 			goto end
 
 		default:
-			// Send the human move to engine and get a counter move
-			engineMoveNext(eng, gGame, cmd)
+			if gHumanVsHuman {
+				humanMove(gGame, cmd)
+			} else {
+				// Send the human move to engine and get a counter move
+				engineMoveNext(eng, gGame, cmd)
+			}
 			gameStarted = true
 			if isGameOver(gGame) {
 				// Save the game.
-				if savePGN(gGame, gGameFilename) == nil { // Success
-					fmt.Println("Game saved to", gConsole.Bold(gConsole.Red(gGameFilename)))
-
+				if filename, err := saveGame(gGame); err == nil { // Success
 					// If analysis is request, upload the game to lichess.org and open it in a browser.
 					if gLichessAuthTok != "" {
 						lic := NewLichessClient(gLichessAuthTok, "Piñata "+gVersion)
-						_, url, err := lic.Import(gGameFilename)
+						_, url, err := lic.Import(filename)
 						if err != nil {
 							fmt.Println("Unable to export the game to https://lichess.org,", err)
 							goto end
@@ -287,4 +548,10 @@ func shell() {
 		}
 	}
 end:
+	gJournal.remove()
+
+	// Lichess analyzes the game when given an access-token, otherwise offer the local engine.
+	if eng != nil && gLichessAuthTok == "" && gGame.Outcome() != chess.NoOutcome && len(gGame.Moves()) > 0 && gLibraryFile != "" {
+		offerAnalysis(l, eng, gGame)
+	}
 }
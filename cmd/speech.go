@@ -0,0 +1,119 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+)
+
+// Speech command of --speak when no command is given.
+func defaultSpeechCommand() string {
+	if runtime.GOOS == "darwin" {
+		return "say"
+	}
+	return "espeak"
+}
+
+var gSpokenPieces = map[byte]string{'K': "King", 'Q': "Queen", 'R': "Rook", 'B': "Bishop", 'N': "Knight"}
+
+var gSpokenRanks = []string{"one", "two", "three", "four", "five", "six", "seven", "eight"}
+
+// Render a SAN move in words, like "Knight takes f three, check".
+func sanToWords(san string) string {
+	suffix := ""
+	switch {
+	case strings.HasSuffix(san, "#"):
+		suffix = ", checkmate"
+	case strings.HasSuffix(san, "+"):
+		suffix = ", check"
+	}
+	san = strings.TrimRight(san, "+#!?")
+
+	switch san {
+	case "O-O":
+		return "castles king side" + suffix
+	case "O-O-O":
+		return "castles queen side" + suffix
+	}
+
+	var words []string
+	for i := 0; i < len(san); i++ {
+		c := san[i]
+		switch {
+		case gSpokenPieces[c] != "":
+			words = append(words, gSpokenPieces[c])
+		case c == 'x':
+			words = append(words, "takes")
+		case c == '=':
+			words = append(words, "promotes to")
+		case c >= 'a' && c <= 'h':
+			words = append(words, string(c))
+		case c >= '1' && c <= '8':
+			words = append(words, gSpokenRanks[c-'1'])
+		}
+	}
+	return strings.Join(words, " ") + suffix
+}
+
+// Speak the text with the --speak command in the background, so the prompt
+// does not wait for it. Speech is turned off if the command fails to start.
+func speak(text string) {
+	if gSpeak == "" {
+		return
+	}
+	args := strings.Fields(gSpeak)
+	cmd := exec.Command(args[0], append(args[1:], text)...)
+	if err := cmd.Start(); err != nil {
+		fmt.Println("Unable to speak with", gConsole.Bold(gConsole.Red(gSpeak)).String()+",", err)
+		gSpeak = ""
+		return
+	}
+	go cmd.Wait() // Reap the process once it is done speaking.
+}
+
+// Split a method name like "ThreefoldRepetition" into words.
+func spokenMethod(method string) string {
+	var sb strings.Builder
+	for i, r := range method {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			sb.WriteByte(' ')
+		}
+		sb.WriteRune(r)
+	}
+	return strings.ToLower(sb.String())
+}
+
+// SAN of the last move played.
+func lastMoveSAN(game *chess.Game) string {
+	moves := game.Moves()
+	if len(moves) == 0 {
+		return ""
+	}
+	return chess.Encoder.Encode(chess.AlgebraicNotation{}, game.Positions()[len(moves)-1], moves[len(moves)-1])
+}
+
+// Speak the last move, the engine's or else the human's with --speak-human.
+func speakMove(game *chess.Game, byEngine bool) {
+	if byEngine || gSpeakHuman || gHumanVsHuman {
+		speak(sanToWords(lastMoveSAN(game)))
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSanToWords(t *testing.T) {
+	tests := []struct {
+		san, words string
+	}{
+		{"e4", "e four"},
+		{"Nf3", "Knight f three"},
+		{"exd5", "e takes d five"},
+		{"Bxf7+", "Bishop takes f seven, check"},
+		{"Qh5#", "Queen h five, checkmate"},
+		{"Nbd2", "Knight b d two"},
+		{"R1e2", "Rook one e two"},
+		{"e8=Q", "e eight promotes to Queen"},
+		{"dxc1=N+", "d takes c one promotes to Knight, check"},
+		{"O-O", "castles king side"},
+		{"O-O-O+", "castles queen side, check"},
+		{"Kxe2!?", "King takes e two"},
+	}
+	for _, test := range tests {
+		if words := sanToWords(test.san); words != test.words {
+			t.Errorf("sanToWords(%q) = %q, want %q", test.san, words, test.words)
+		}
+	}
+}
+
+func TestSpokenMethod(t *testing.T) {
+	tests := []struct {
+		method, words string
+	}{
+		{"Checkmate", "checkmate"},
+		{"ThreefoldRepetition", "threefold repetition"},
+		{"InsufficientMaterial", "insufficient material"},
+	}
+	for _, test := range tests {
+		if words := spokenMethod(test.method); words != test.words {
+			t.Errorf("spokenMethod(%q) = %q, want %q", test.method, words, test.words)
+		}
+	}
+}
+
+func TestSpeak(t *testing.T) {
+	defer func(command string) { gSpeak = command }(gSpeak)
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("no sleep command to speak with")
+	}
+
+	// Speech goes on in the background.
+	gSpeak = sleep
+	start := time.Now()
+	speak("2")
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("speak waited %v for the command", elapsed)
+	}
+
+	gSpeak = filepath.Join(t.TempDir(), "no-such-command")
+	captureOutput(t, func() { speak("e four") })
+	if gSpeak != "" {
+		t.Error("speech is still on after the command failed to start")
+	}
+}
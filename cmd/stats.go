@@ -0,0 +1,265 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// statsCmd summarizes the results of the saved games
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Summarize your results over the saved games.",
+	Long: `Wins, draws and losses of your finished games against engines, broken down by
+color, engine, engine level, opening, blind or visual play, game length and how
+the games ended, with your streaks and a month by month trend.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		onStart()
+		games, err := filterGames(libraryGames())
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		showStats(games)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+
+	statsCmd.Flags().StringVar(&gGamesOpponent, "opponent", "", "only games against engines whose name contains this")
+	statsCmd.Flags().StringVar(&gGamesSince, "since", "", "only games played on or after this date (YYYY-MM-DD)")
+	statsCmd.Flags().StringVar(&gGamesUntil, "until", "", "only games played on or before this date (YYYY-MM-DD)")
+}
+
+// Scores of the groups of one breakdown, in the order the groups were seen.
+type scoreBreakdown struct {
+	title  string
+	groups []string
+	scores map[string]*matchScore
+}
+
+// Count a result for the group.
+func (b *scoreBreakdown) add(group, result string) {
+	if b.scores == nil {
+		b.scores = make(map[string]*matchScore)
+	}
+	score, ok := b.scores[group]
+	if !ok {
+		score = &matchScore{}
+		b.scores[group] = score
+		b.groups = append(b.groups, group)
+	}
+	score.add(result)
+}
+
+// Score as a percentage of the points available.
+func scorePercent(s matchScore) string {
+	if s.games() == 0 {
+		return "-"
+	}
+	return strconv.Itoa(int(100*s.points()/float64(s.games())+0.5)) + "%"
+}
+
+// Length bucket of a game by its full moves.
+func lengthGroup(moves int) string {
+	switch {
+	case moves < 20:
+		return "under 20 moves"
+	case moves < 40:
+		return "20-39 moves"
+	case moves < 60:
+		return "40-59 moves"
+	}
+	return "60+ moves"
+}
+
+// How a saved game ended, from its tags or else the final position.
+func savedGameMethod(game *chess.Game) string {
+	if termination := GetTagPair(game, "Termination"); termination != "" {
+		return termination
+	}
+	method := GetTagPair(game, "Method")
+	if method == "" && game.Method() != chess.NoMethod {
+		method = game.Method().String()
+	}
+	if method == "" {
+		return "unknown"
+	}
+	return spokenMethod(method)
+}
+
+// Average memory check accuracy of the game, -1 if none was taken.
+func memCheckAccuracy(game *chess.Game) int {
+	checks := strings.Fields(GetTagPair(game, "MemChecks"))
+	total := 0
+	for _, check := range checks {
+		parts := strings.SplitN(check, ":", 2)
+		if len(parts) != 2 {
+			return -1
+		}
+		accuracy, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return -1
+		}
+		total += accuracy
+	}
+	if len(checks) == 0 {
+		return -1
+	}
+	return total / len(checks)
+}
+
+// A month of results for the trend.
+type monthStats struct {
+	month            string
+	score            matchScore
+	blind            int
+	recall, recalled int // Sum of memory check accuracy and games with checks.
+}
+
+// Longest run of results within the allowed ones, and the current run.
+func streaks(results []string, allowed string) (longest, current int) {
+	for _, result := range results {
+		if strings.Contains(allowed, result) {
+			current++
+			if current > longest {
+				longest = current
+			}
+		} else {
+			current = 0
+		}
+	}
+	return longest, current
+}
+
+// Results of the finished games against engines.
+type gameStats struct {
+	breakdowns []*scoreBreakdown
+	total      matchScore
+	results    []string // In the order of the library, for the streaks.
+	months     []*monthStats
+	skipped    int // Unfinished or between humans.
+}
+
+// Count the results of the games by breakdown and by month.
+func collectStats(games []libraryGame) *gameStats {
+	stats := &gameStats{breakdowns: []*scoreBreakdown{
+		{title: "Color"}, {title: "Engine"}, {title: "Level"}, {title: "Opening"},
+		{title: "Board"}, {title: "Length"}, {title: "Ending"},
+	}}
+	for _, g := range games {
+		result := humanResult(g.game)
+		if result == "" {
+			stats.skipped++
+			continue
+		}
+		stats.results = append(stats.results, result)
+
+		level := GetTagPair(g.game, "EngineLevel")
+		if level == "" {
+			level = "full strength"
+		}
+		opening := "unknown"
+		if eco := GetTagPair(g.game, "ECO"); eco != "" {
+			opening = eco + " " + GetTagPair(g.game, "Opening")
+		}
+		board := "blind"
+		if GetTagPair(g.game, "Visual") == "true" {
+			board = "visual"
+		}
+		for i, group := range []string{humanSide(g.game).Name(), gameOpponent(g.game), level, opening,
+			board, lengthGroup(fullMoves(g.game)), savedGameMethod(g.game)} {
+			stats.breakdowns[i].add(group, result)
+		}
+		stats.total.add(result)
+
+		// Month by month, in the order of the library.
+		month := strings.ReplaceAll(GetTagPair(g.game, "Date"), ".", "-")
+		if len(month) >= 7 {
+			month = month[:7]
+		}
+		if len(stats.months) == 0 || stats.months[len(stats.months)-1].month != month {
+			stats.months = append(stats.months, &monthStats{month: month})
+		}
+		m := stats.months[len(stats.months)-1]
+		m.score.add(result)
+		if board == "blind" {
+			m.blind++
+		}
+		if accuracy := memCheckAccuracy(g.game); accuracy >= 0 {
+			m.recall += accuracy
+			m.recalled++
+		}
+	}
+	return stats
+}
+
+// Print the breakdowns, streaks and monthly trend of the games.
+func showStats(games []libraryGame) {
+	stats := collectStats(games)
+	if stats.total.games() == 0 {
+		fmt.Println("No finished games against engines in", gConsole.Bold(gConsole.Yellow(libraryDir())))
+		return
+	}
+	fmt.Println("Games:", gConsole.Bold(gConsole.Yellow(stats.total.games())).String()+",",
+		"wins", stats.total.wins, "draws", stats.total.draws, "losses", stats.total.losses,
+		"("+scorePercent(stats.total)+")")
+	if stats.skipped > 0 {
+		fmt.Println(stats.skipped, "unfinished or human vs human game(s) left out.")
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"By", "Group", "Games", "Wins", "Draws", "Losses", "Score"})
+	table.SetAutoMergeCellsByColumnIndex([]int{0})
+	table.SetRowLine(true)
+	for _, b := range stats.breakdowns {
+		for _, group := range b.groups {
+			s := *b.scores[group]
+			table.Append([]string{b.title, group, strconv.Itoa(s.games()), strconv.Itoa(s.wins),
+				strconv.Itoa(s.draws), strconv.Itoa(s.losses), scorePercent(s)})
+		}
+	}
+	table.Render()
+
+	longestWins, currentWins := streaks(stats.results, "win")
+	longestUnbeaten, currentUnbeaten := streaks(stats.results, "win draw")
+	fmt.Println("Streaks: current", currentWins, "win(s) and", currentUnbeaten, "unbeaten, longest",
+		longestWins, "win(s) and", longestUnbeaten, "unbeaten.")
+
+	trend := tablewriter.NewWriter(os.Stdout)
+	trend.SetHeader([]string{"Month", "Games", "Wins", "Draws", "Losses", "Score", "Blind", "Recall"})
+	trend.SetAlignment(tablewriter.ALIGN_RIGHT)
+	for _, m := range stats.months {
+		recall := "-"
+		if m.recalled > 0 {
+			recall = strconv.Itoa(m.recall/m.recalled) + "%"
+		}
+		trend.Append([]string{m.month, strconv.Itoa(m.score.games()), strconv.Itoa(m.score.wins),
+			strconv.Itoa(m.score.draws), strconv.Itoa(m.score.losses), scorePercent(m.score),
+			strconv.Itoa(m.blind), recall})
+	}
+	trend.Render()
+}
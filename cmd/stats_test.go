@@ -0,0 +1,127 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStreaks(t *testing.T) {
+	tests := []struct {
+		results          []string
+		allowed          string
+		longest, current int
+	}{
+		{nil, "win", 0, 0},
+		{[]string{"win", "win", "loss", "win"}, "win", 2, 1},
+		{[]string{"win", "draw", "win", "loss"}, "win", 1, 0},
+		{[]string{"win", "draw", "win", "loss"}, "win draw", 3, 0},
+		{[]string{"loss", "draw", "draw", "win"}, "win draw", 3, 3},
+		{[]string{"loss", "loss"}, "win draw", 0, 0},
+	}
+	for _, test := range tests {
+		longest, current := streaks(test.results, test.allowed)
+		if longest != test.longest || current != test.current {
+			t.Errorf("streaks(%q, %q) = %d, %d, want %d, %d", test.results, test.allowed,
+				longest, current, test.longest, test.current)
+		}
+	}
+}
+
+func TestLengthGroup(t *testing.T) {
+	for moves, group := range map[int]string{0: "under 20 moves", 19: "under 20 moves", 20: "20-39 moves",
+		45: "40-59 moves", 60: "60+ moves", 120: "60+ moves"} {
+		if got := lengthGroup(moves); got != group {
+			t.Errorf("lengthGroup(%d) = %q, want %q", moves, got, group)
+		}
+	}
+}
+
+func TestMemCheckAccuracy(t *testing.T) {
+	tests := []struct {
+		checks   string
+		accuracy int
+	}{
+		{"", -1},
+		{"10:80", 80},
+		{"10:80 20:100 30:60", 80},
+		{"10:80 20", -1},
+		{"10:x", -1},
+	}
+	for _, test := range tests {
+		if accuracy := memCheckAccuracy(taggedGame("MemChecks", test.checks)); accuracy != test.accuracy {
+			t.Errorf("memCheckAccuracy(%q) = %d, want %d", test.checks, accuracy, test.accuracy)
+		}
+	}
+}
+
+func TestCollectStats(t *testing.T) {
+	game := func(white, black, result, date string, tags ...string) libraryGame {
+		tags = append(tags, "White", white, "Black", black, "Result", result, "Date", date)
+		return libraryGame{game: taggedGame(tags...)}
+	}
+	games := []libraryGame{
+		game("Human", "stockfish", "1-0", "2020.09.28"),
+		game("Human", "stockfish", "0-1", "2020-10-02", "Visual", "true"),
+		game("lc0", "Human", "0-1", "2020-10-15", "MemChecks", "10:90"),
+		game("lc0", "Human", "1/2-1/2", "2020-10-30", "MemChecks", "10:70 20:50"),
+		game("Human", "stockfish", "*", "2020-10-31"),
+		game("Ann", "Bob", "1-0", "2020-11-01", "Duel", "true"),
+		game("Human", "stockfish", "1-0", "2020-11-02", "EngineLevel", "Elo 1500"),
+	}
+	stats := collectStats(games)
+
+	if stats.total != (matchScore{wins: 3, draws: 1, losses: 1}) || stats.skipped != 2 {
+		t.Errorf("total %+v with %d skipped, want 3 wins, 1 draw, 1 loss and 2 skipped", stats.total, stats.skipped)
+	}
+	if want := []string{"win", "loss", "win", "draw", "win"}; !reflect.DeepEqual(stats.results, want) {
+		t.Errorf("results = %q, want %q", stats.results, want)
+	}
+
+	months := make([]monthStats, len(stats.months))
+	for i, m := range stats.months {
+		months[i] = *m
+	}
+	wantMonths := []monthStats{
+		{month: "2020-09", score: matchScore{wins: 1}, blind: 1},
+		{month: "2020-10", score: matchScore{wins: 1, draws: 1, losses: 1}, blind: 2, recall: 150, recalled: 2},
+		{month: "2020-11", score: matchScore{wins: 1}, blind: 1},
+	}
+	if !reflect.DeepEqual(months, wantMonths) {
+		t.Errorf("months = %+v, want %+v", months, wantMonths)
+	}
+
+	breakdowns := make(map[string]map[string]matchScore)
+	for _, b := range stats.breakdowns {
+		breakdowns[b.title] = make(map[string]matchScore)
+		for group, score := range b.scores {
+			breakdowns[b.title][group] = *score
+		}
+	}
+	wantBreakdowns := map[string]map[string]matchScore{
+		"Color":  {"White": {wins: 2, losses: 1}, "Black": {wins: 1, draws: 1}},
+		"Engine": {"stockfish": {wins: 2, losses: 1}, "lc0": {wins: 1, draws: 1}},
+		"Level":  {"full strength": {wins: 2, draws: 1, losses: 1}, "Elo 1500": {wins: 1}},
+		"Board":  {"blind": {wins: 3, draws: 1}, "visual": {losses: 1}},
+	}
+	for title, want := range wantBreakdowns {
+		if !reflect.DeepEqual(breakdowns[title], want) {
+			t.Errorf("%s breakdown = %+v, want %+v", title, breakdowns[title], want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+	"github.com/abperiasamy/pinata/uci"
+)
+
+// Rebuild the game from its starting position, replaying only the given moves.
+func replayGame(game *chess.Game, moves []*chess.Move) (*chess.Game, error) {
+	fen, err := chess.FEN(game.Positions()[0].String())
+	if err != nil {
+		return nil, err
+	}
+
+	g := chess.NewGame(fen, chess.TagPairs(game.TagPairs()), chess.UseNotation(chess.AlgebraicNotation{}))
+	for _, move := range moves {
+		if err = g.Move(move); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// Number of full move pairs requested by "/undo [n]" or "/redo [n]".
+func takebackCount(cmd string) (int, error) {
+	args := strings.Fields(cmd)
+	if len(args) < 2 {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(args[1])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid move count %q", args[1])
+	}
+	return n, nil
+}
+
+// Plies in a takeback of n moves. Against the engine a move is the human's
+// move and the engine's reply, between humans it is the last move alone.
+func takebackPlies(n int) int {
+	if gHumanVsHuman {
+		return n
+	}
+	return 2 * n
+}
+
+// Take back the last n moves and resync the engine.
+func undoMoves(engine *uci.Engine, n int) {
+	moves := gGame.Moves()
+
+	// Engine's opening move as white cannot be taken back, it will only be replayed.
+	undoable := len(moves)
+	if !gHumanVsHuman {
+		if gGame.Positions()[0].Turn() != humanColor() {
+			undoable--
+		}
+		undoable -= undoable % 2
+	}
+	if undoable < 1 {
+		fmt.Println("Nothing to undo.")
+		return
+	}
+
+	plies := takebackPlies(n)
+	if plies > undoable {
+		plies = undoable
+	}
+
+	keep := len(moves) - plies
+	game, err := replayGame(gGame, moves[:keep])
+	if err != nil {
+		fmt.Println("Unable to take back,", err)
+		return
+	}
+
+	gGame = game
+	gRedoMoves = append(append([]*chess.Move(nil), moves[keep:]...), gRedoMoves...)
+	taken := plies / takebackPlies(1)
+	gTakebacks += taken
+	if len(gClockHistory) > keep {
+		gClockHistory = gClockHistory[:keep]
+	}
+	restoreClock(gGame)
+	if engine != nil {
+		engine.SetFEN(gGame.FEN())
+	}
+	syncMoveCount(gGame)
+
+	fmt.Println("Took back", gConsole.Bold(gConsole.Yellow(taken)), "move(s).")
+	drawBoard(gGame)
+}
+
+// Replay the last n moves taken back by undo.
+func redoMoves(engine *uci.Engine, n int) {
+	if len(gRedoMoves) < takebackPlies(1) {
+		fmt.Println("Nothing to redo.")
+		return
+	}
+
+	plies := takebackPlies(n)
+	if plies > len(gRedoMoves) {
+		plies = len(gRedoMoves) - len(gRedoMoves)%takebackPlies(1)
+	}
+
+	game, err := replayGame(gGame, append(gGame.Moves(), gRedoMoves[:plies]...))
+	if err != nil {
+		fmt.Println("Unable to redo,", err)
+		return
+	}
+
+	gGame = game
+	gRedoMoves = gRedoMoves[plies:]
+	if engine != nil {
+		engine.SetFEN(gGame.FEN())
+	}
+	syncMoveCount(gGame)
+
+	fmt.Println("Replayed", gConsole.Bold(gConsole.Yellow(plies/takebackPlies(1))), "move(s).")
+	drawBoard(gGame)
+}
@@ -0,0 +1,158 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abperiasamy/chess"
+)
+
+func TestTakebackCount(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want int
+	}{
+		{"/undo", 1},
+		{"/undo 3", 3},
+		{"/redo  2", 2},
+	}
+	for _, test := range tests {
+		if got, err := takebackCount(test.cmd); err != nil || got != test.want {
+			t.Errorf("takebackCount(%q) = %d, %v, want %d", test.cmd, got, err, test.want)
+		}
+	}
+	for _, cmd := range []string{"/undo 0", "/undo -1", "/redo two"} {
+		if _, err := takebackCount(cmd); err == nil {
+			t.Errorf("takebackCount(%q) succeeded, want an error", cmd)
+		}
+	}
+}
+
+func TestUndoRedo(t *testing.T) {
+	engine := startFakeEngine(t)
+	gHumanIsBlack = false
+	gGame = playLAN(t, "e2e4 e7e5 g1f3 b8c6 f1c4 g8f6")
+	gRedoMoves, gTakebacks = nil, 0
+	t.Cleanup(func() { gGame, gRedoMoves, gTakebacks = nil, nil, 0 })
+
+	steps := []struct {
+		undo      bool // Otherwise redo.
+		n         int
+		moves     string
+		redo      string // Moves left to redo.
+		takebacks int
+	}{
+		{true, 1, "e2e4 e7e5 g1f3 b8c6", "f1c4 g8f6", 1},   // The human's move and the engine's reply.
+		{true, 5, "", "e2e4 e7e5 g1f3 b8c6 f1c4 g8f6", 3},  // No more moves than played.
+		{true, 1, "", "e2e4 e7e5 g1f3 b8c6 f1c4 g8f6", 3},  // Nothing to undo.
+		{false, 2, "e2e4 e7e5 g1f3 b8c6", "f1c4 g8f6", 3},  // Replayed in order.
+		{false, 5, "e2e4 e7e5 g1f3 b8c6 f1c4 g8f6", "", 3}, // No more moves than taken back.
+		{true, 2, "e2e4 e7e5", "g1f3 b8c6 f1c4 g8f6", 5},   // Taken back again.
+	}
+	for i, step := range steps {
+		if step.undo {
+			undoMoves(engine, step.n)
+		} else {
+			redoMoves(engine, step.n)
+		}
+		redo := movesLAN(gGame.Position(), gRedoMoves)
+		if got := gameLAN(gGame); got != step.moves || redo != step.redo || gTakebacks != step.takebacks {
+			t.Errorf("step %d: moves %q, redo %q, %d takebacks, want %q, %q, %d",
+				i, got, redo, gTakebacks, step.moves, step.redo, step.takebacks)
+		}
+	}
+	if gMoveCount != 2 {
+		t.Errorf("move count after taking back to move 2 is %d", gMoveCount)
+	}
+
+	// A new move discards the moves taken back.
+	if err := engineMoveNext(engine, gGame, "Bc4"); err != nil {
+		t.Fatal(err)
+	}
+	if len(gRedoMoves) != 0 {
+		t.Errorf("moves left to redo after a new move: %s", movesLAN(gGame.Position(), gRedoMoves))
+	}
+	redoMoves(engine, 1)
+	if got, want := gameLAN(gGame), "e2e4 e7e5 f1c4 a7a5"; got != want {
+		t.Errorf("moves after redo = %q, want %q", got, want)
+	}
+}
+
+func TestUndoClock(t *testing.T) {
+	engine := startFakeEngine(t)
+	gHumanIsBlack = false
+	gGame = playLAN(t, "e2e4 e7e5 g1f3 b8c6 f1c4 g8f6")
+	gRedoMoves, gTakebacks = nil, 0
+	gClock, _ = newChessClock("5")
+	gClockHistory = []time.Duration{290 * time.Second, 295 * time.Second, 280 * time.Second, 285 * time.Second, 270 * time.Second, 275 * time.Second}
+	gClock.remaining[chess.White], gClock.remaining[chess.Black] = 270*time.Second, 275*time.Second
+	t.Cleanup(func() { gGame, gRedoMoves, gTakebacks, gClock, gClockHistory = nil, nil, 0, nil, nil })
+
+	// Both sides get back the time they had after the moves kept.
+	undoMoves(engine, 1)
+	if white, black := gClock.remaining[chess.White], gClock.remaining[chess.Black]; white != 280*time.Second || black != 285*time.Second {
+		t.Errorf("clocks after undo are %v and %v, want 4m40s and 4m45s", white, black)
+	}
+	undoMoves(engine, 2)
+	if white, black := gClock.remaining[chess.White], gClock.remaining[chess.Black]; white != 5*time.Minute || black != 5*time.Minute {
+		t.Errorf("clocks after taking back every move are %v and %v, want the base time", white, black)
+	}
+}
+
+func TestUndoEngineFirstMove(t *testing.T) {
+	engine := startFakeEngine(t)
+	gHumanIsBlack = true
+	gGame = playLAN(t, "e2e4 e7e5 g1f3 b8c6")
+	gRedoMoves, gTakebacks = nil, 0
+	t.Cleanup(func() { gGame, gRedoMoves, gTakebacks, gHumanIsBlack = nil, nil, 0, false })
+
+	// The engine's opening move stays, it would only be played again.
+	undoMoves(engine, 3)
+	if got := gameLAN(gGame); got != "e2e4 e7e5" {
+		t.Errorf("moves after undo = %q, want e2e4 e7e5", got)
+	}
+	undoMoves(engine, 1)
+	if got := gameLAN(gGame); got != "e2e4 e7e5" {
+		t.Errorf("moves after undo = %q, want e2e4 e7e5", got)
+	}
+}
+
+func TestUndoRedoDuel(t *testing.T) {
+	gHumanVsHuman = true
+	gGame = playLAN(t, "e2e4 e7e5 g1f3")
+	gRedoMoves, gTakebacks = nil, 0
+	t.Cleanup(func() { gGame, gRedoMoves, gTakebacks, gHumanVsHuman = nil, nil, 0, false })
+
+	// Between humans a move is a single ply.
+	undoMoves(nil, 1)
+	if got := gameLAN(gGame); got != "e2e4 e7e5" || gTakebacks != 1 {
+		t.Errorf("moves after undo = %q with %d takebacks, want e2e4 e7e5 with 1", got, gTakebacks)
+	}
+	undoMoves(nil, 5)
+	redoMoves(nil, 1)
+	if got := gameLAN(gGame); got != "e2e4" || gTakebacks != 3 {
+		t.Errorf("moves after redo = %q with %d takebacks, want e2e4 with 3", got, gTakebacks)
+	}
+	if err := humanMove(gGame, "d5"); err != nil {
+		t.Fatal(err)
+	}
+	if len(gRedoMoves) != 0 {
+		t.Errorf("moves left to redo after a new move: %s", movesLAN(gGame.Position(), gRedoMoves))
+	}
+}
@@ -0,0 +1,569 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package uci talks to chess engines over the Universal Chess Interface.
+// See http://wbec-ridderkerk.nl/html/UCIProtocol.html
+package uci
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Time an engine gets to answer "uci" and "isready", so a hung engine is not
+// waited on forever.
+var handshakeTimeout = 10 * time.Second
+
+// Constants for result filtering
+const (
+	HighestDepthOnly   uint = 1 << iota // only return the highest depth results
+	IncludeUpperbounds                  // include upperbound results
+	IncludeLowerbounds                  // include lowerbound results
+)
+
+// Options for initializing the chess engine
+type Options struct {
+	MultiPV int  // number of principal variations (ranks top X moves)
+	Hash    int  // hash size in MB
+	Ponder  bool // whether the engine should ponder
+	OwnBook bool // whether the engine should use its opening book
+	Threads int  // max number of threads the engine should use
+}
+
+// Limits bound a single search. Zero values are not sent to the engine.
+type Limits struct {
+	Depth     int           // search depth in plies
+	Nodes     int           // number of nodes to search
+	MoveTime  time.Duration // exact time to search
+	WhiteTime time.Duration // white's remaining clock time
+	BlackTime time.Duration // black's remaining clock time
+	WhiteInc  time.Duration // white's increment per move
+	BlackInc  time.Duration // black's increment per move
+}
+
+// String renders the limits as the arguments of the UCI "go" command.
+func (l Limits) String() string {
+	var args []string
+	add := func(name string, value int64) {
+		if value > 0 {
+			args = append(args, name, strconv.FormatInt(value, 10))
+		}
+	}
+	add("wtime", l.WhiteTime.Milliseconds())
+	add("btime", l.BlackTime.Milliseconds())
+	add("winc", l.WhiteInc.Milliseconds())
+	add("binc", l.BlackInc.Milliseconds())
+	add("depth", int64(l.Depth))
+	add("nodes", int64(l.Nodes))
+	add("movetime", l.MoveTime.Milliseconds())
+	return strings.Join(args, " ")
+}
+
+// ScoreResult holds a single "info" record reported by the engine
+type ScoreResult struct {
+	Time           int      // time spent to get this result (ms)
+	Depth          int      // depth (number of plies) of result record
+	SelDepth       int      // selective depth -- some engines don't report this
+	Nodes          int      // total nodes searched to get this result
+	NodesPerSecond int      // current nodes per second rate
+	MultiPV        int      // 0 if MultiPV not set
+	Lowerbound     bool     // true if reported as lowerbound
+	Upperbound     bool     // true if reported as upperbound
+	Score          int      // score centipawns or mate in X if Mate is true
+	Mate           bool     // whether this move results in forced mate
+	BestMoves      []string // best line for this result
+}
+
+// Results holds the score records of a search along with the chosen move
+type Results struct {
+	BestMove string
+	Ponder   string // reply the engine expects, empty if not reported
+	Results  []ScoreResult
+}
+
+func (r Results) String() string {
+	b, _ := json.MarshalIndent(r, "", "  ")
+	return fmt.Sprintln(string(b))
+}
+
+// Option is a parameter the engine advertises during the handshake
+type Option struct {
+	Name    string
+	Type    string // check, spin, combo, button or string
+	Default string
+	Min     int      // lower bound of a spin option
+	Max     int      // upper bound of a spin option
+	Vars    []string // choices of a combo option
+}
+
+// Engine holds the information needed to communicate with a chess engine
+// executable. Engines should be created with a call to NewEngine.
+type Engine struct {
+	Name    string            // engine name reported by "id name"
+	Options map[string]Option // advertised options, keyed by lower case name
+
+	cmd    *exec.Cmd
+	stdout *bufio.Reader
+	stdin  *bufio.Writer
+	ponder chan searchResult // background search started by Ponder
+}
+
+type searchResult struct {
+	res *Results
+	err error
+}
+
+// NewEngine spins up the engine executable and completes the UCI handshake
+func NewEngine(path string, arg ...string) (*Engine, error) {
+	eng := Engine{}
+	eng.cmd = exec.Command(path, arg...)
+	stdin, err := eng.cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := eng.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := eng.cmd.Start(); err != nil {
+		return nil, err
+	}
+	eng.stdin = bufio.NewWriter(stdin)
+	eng.stdout = bufio.NewReader(stdout)
+	eng.Options = make(map[string]Option)
+
+	if err := eng.send("uci"); err != nil {
+		eng.Close()
+		return nil, err
+	}
+	err = eng.await("uciok", func() error {
+		for {
+			line, err := eng.readLine()
+			if err != nil {
+				return err
+			}
+			if strings.HasPrefix(line, "id name ") {
+				eng.Name = strings.TrimPrefix(line, "id name ")
+			} else if opt, ok := parseOption(line); ok {
+				eng.Options[strings.ToLower(opt.Name)] = opt
+			} else if line == "uciok" {
+				return nil
+			}
+		}
+	})
+	if err != nil {
+		eng.Close()
+		return nil, fmt.Errorf("uci handshake failed: %v", err)
+	}
+	return &eng, nil
+}
+
+// Run the read of the engine's reply within handshakeTimeout. A hung engine
+// is killed, which ends the read.
+func (eng *Engine) await(reply string, read func() error) error {
+	done := make(chan error, 1)
+	go func() { done <- read() }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(handshakeTimeout):
+		eng.cmd.Process.Kill()
+		<-done
+		return fmt.Errorf("no %s from the engine in %v", reply, handshakeTimeout)
+	}
+}
+
+// Option looks up an advertised option by its case insensitive name
+func (eng *Engine) Option(name string) (Option, bool) {
+	opt, ok := eng.Options[strings.ToLower(name)]
+	return opt, ok
+}
+
+// Write a single command to the engine
+func (eng *Engine) send(command string) error {
+	if _, err := eng.stdin.WriteString(command + "\n"); err != nil {
+		return err
+	}
+	return eng.stdin.Flush()
+}
+
+// Read a single line from the engine
+func (eng *Engine) readLine() (string, error) {
+	line, err := eng.stdout.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// SetOptions sends setoption commands to the Engine
+// for the values set in the Options record passed in
+func (eng *Engine) SetOptions(opt Options) error {
+	var err error
+	if opt.MultiPV > 0 {
+		err = eng.SendOption("MultiPV", opt.MultiPV)
+		if err != nil {
+			return err
+		}
+	}
+	if opt.Hash > 0 {
+		err = eng.SendOption("Hash", opt.Hash)
+		if err != nil {
+			return err
+		}
+	}
+	if opt.Threads > 0 {
+		err = eng.SendOption("Threads", opt.Threads)
+		if err != nil {
+			return err
+		}
+	}
+	err = eng.SendOption("OwnBook", opt.OwnBook)
+	if err != nil {
+		return err
+	}
+	return eng.SendOption("Ponder", opt.Ponder)
+}
+
+// SendOption sends setoption command to the Engine
+func (eng *Engine) SendOption(name string, value interface{}) error {
+	eng.idle()
+	return eng.send(fmt.Sprintf("setoption name %s value %v", name, value))
+}
+
+// NewGame tells the engine that the next search is from a different game
+func (eng *Engine) NewGame() error {
+	eng.idle()
+	if err := eng.send("ucinewgame"); err != nil {
+		return err
+	}
+	return eng.IsReady()
+}
+
+// IsReady blocks until the engine has processed all the pending commands
+func (eng *Engine) IsReady() error {
+	eng.idle()
+	if err := eng.send("isready"); err != nil {
+		return err
+	}
+	return eng.await("readyok", func() error {
+		for {
+			line, err := eng.readLine()
+			if err != nil {
+				return err
+			}
+			if line == "readyok" {
+				return nil
+			}
+		}
+	})
+}
+
+// SetFEN takes a FEN string and tells the engine to set the position
+func (eng *Engine) SetFEN(fen string) error {
+	return eng.SetPosition(fen)
+}
+
+// SetPosition sets the position reached by playing the moves from the FEN
+func (eng *Engine) SetPosition(fen string, moves ...string) error {
+	eng.idle()
+	command := "position fen " + fen
+	if len(moves) > 0 {
+		command += " moves " + strings.Join(moves, " ")
+	}
+	return eng.send(command)
+}
+
+// SetMoves sets the position reached by playing the moves from the standard start
+func (eng *Engine) SetMoves(moves string) error {
+	eng.idle()
+	return eng.send("position startpos moves " + moves)
+}
+
+// Go searches the current position within the given limits and waits for the best move.
+func (eng *Engine) Go(limits Limits, resultOpts ...uint) (*Results, error) {
+	eng.idle()
+	if err := eng.send(strings.TrimSpace("go " + limits.String())); err != nil {
+		return nil, err
+	}
+	return eng.collect(resultOpts...)
+}
+
+// Ponder starts searching the current position in the background, assuming
+// its last move is the reply the engine expects. Finish it with PonderHit
+// when that move is played, or with StopPonder otherwise.
+func (eng *Engine) Ponder(limits Limits, resultOpts ...uint) error {
+	eng.idle()
+	if err := eng.send(strings.TrimSpace("go ponder " + limits.String())); err != nil {
+		return err
+	}
+
+	eng.ponder = make(chan searchResult, 1)
+	go func(done chan<- searchResult) {
+		res, err := eng.collect(resultOpts...)
+		done <- searchResult{res, err}
+	}(eng.ponder)
+	return nil
+}
+
+// Pondering reports whether a background search started by Ponder is running
+func (eng *Engine) Pondering() bool {
+	return eng.ponder != nil
+}
+
+// PonderHit turns the background search into a regular one and waits for the best move
+func (eng *Engine) PonderHit() (*Results, error) {
+	if eng.ponder == nil {
+		return nil, fmt.Errorf("engine is not pondering")
+	}
+	if err := eng.send("ponderhit"); err != nil {
+		return nil, err
+	}
+	result := <-eng.ponder
+	eng.ponder = nil
+	return result.res, result.err
+}
+
+// StopPonder abandons the background search
+func (eng *Engine) StopPonder() error {
+	if eng.ponder == nil {
+		return nil
+	}
+	err := eng.send("stop")
+	result := <-eng.ponder
+	eng.ponder = nil
+	if err != nil {
+		return err
+	}
+	return result.err
+}
+
+// The engine accepts new commands only when it is not searching.
+func (eng *Engine) idle() {
+	if eng.ponder != nil {
+		eng.StopPonder()
+	}
+}
+
+// Read the engine output of a search until its best move.
+func (eng *Engine) collect(resultOpts ...uint) (*Results, error) {
+	resultOpt := uint(0)
+	if len(resultOpts) == 1 {
+		resultOpt = resultOpts[0]
+	}
+
+	res := Results{}
+	latest := map[scoreKey]ScoreResult{}
+	for {
+		line, err := eng.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(line, "bestmove") {
+			fields := strings.Fields(line)
+			if len(fields) > 1 {
+				res.BestMove = fields[1]
+			}
+			if len(fields) > 3 && fields[2] == "ponder" {
+				res.Ponder = fields[3]
+			}
+			break
+		}
+		if r, ok := parseInfo(line); ok {
+			latest[scoreKey{r.Depth, r.MultiPV, r.Upperbound, r.Lowerbound}] = r
+		}
+	}
+
+	maxDepth := 0
+	for _, v := range latest {
+		if v.Depth > maxDepth {
+			maxDepth = v.Depth
+		}
+	}
+	for _, v := range latest {
+		if resultOpt&HighestDepthOnly != 0 && v.Depth != maxDepth {
+			continue
+		}
+		if resultOpt&IncludeUpperbounds == 0 && v.Upperbound {
+			continue
+		}
+		if resultOpt&IncludeLowerbounds == 0 && v.Lowerbound {
+			continue
+		}
+		res.Results = append(res.Results, v)
+	}
+	sort.Sort(byDepth(res.Results))
+	return &res, nil
+}
+
+// GoDepth searches the current position to the given depth
+func (eng *Engine) GoDepth(depth int, resultOpts ...uint) (*Results, error) {
+	return eng.Go(Limits{Depth: depth}, resultOpts...)
+}
+
+// Close asks the engine to quit and reaps the process
+func (eng *Engine) Close() {
+	eng.idle()
+	eng.send("stop")
+	eng.send("quit")
+
+	done := make(chan error, 1)
+	go func() { done <- eng.cmd.Wait() }()
+	select {
+	case <-done:
+	case <-time.After(time.Second): // Engine ignored quit.
+		eng.cmd.Process.Kill()
+		<-done
+	}
+}
+
+// scoreKey identifies the latest unique result of a search
+type scoreKey struct {
+	Depth      int
+	MultiPV    int
+	Upperbound bool
+	Lowerbound bool
+}
+
+type byDepth []ScoreResult
+
+func (a byDepth) Len() int      { return len(a) }
+func (a byDepth) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byDepth) Less(i, j int) bool {
+	if a[i].Depth == a[j].Depth {
+		if a[i].MultiPV == a[j].MultiPV {
+			if a[i].Lowerbound == a[j].Lowerbound {
+				return a[i].Upperbound && !a[j].Upperbound
+			}
+			return a[i].Lowerbound && !a[j].Lowerbound
+		}
+		return a[i].MultiPV < a[j].MultiPV
+	}
+	return a[i].Depth < a[j].Depth
+}
+
+// Parse an "info" line carrying a score. Other lines are ignored.
+func parseInfo(line string) (ScoreResult, bool) {
+	r := ScoreResult{}
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != "info" {
+		return r, false
+	}
+
+	atoi := func(i int) int {
+		if i < len(fields) {
+			n, _ := strconv.Atoi(fields[i])
+			return n
+		}
+		return 0
+	}
+
+	scored := false
+	for i := 1; i < len(fields); i++ {
+		switch fields[i] {
+		case "string", "currmove", "currmovenumber", "refutation", "currline":
+			return r, false // Progress reports and free text carry no score.
+		case "depth":
+			i++
+			r.Depth = atoi(i)
+		case "seldepth":
+			i++
+			r.SelDepth = atoi(i)
+		case "time":
+			i++
+			r.Time = atoi(i)
+		case "nodes":
+			i++
+			r.Nodes = atoi(i)
+		case "nps":
+			i++
+			r.NodesPerSecond = atoi(i)
+		case "multipv":
+			i++
+			r.MultiPV = atoi(i)
+		case "lowerbound":
+			r.Lowerbound = true
+		case "upperbound":
+			r.Upperbound = true
+		case "score":
+			if i+2 < len(fields) {
+				r.Mate = fields[i+1] == "mate"
+				r.Score = atoi(i + 2)
+				scored = true
+			}
+			i += 2
+		case "pv":
+			r.BestMoves = append([]string(nil), fields[i+1:]...)
+			i = len(fields)
+		}
+	}
+	return r, scored && r.Depth > 0
+}
+
+// Parse an "option name <id> type <t> [default <x>] [min <x>] [max <x>] [var <x>]*" line.
+func parseOption(line string) (Option, bool) {
+	opt := Option{}
+	fields := strings.Fields(line)
+	if len(fields) < 5 || fields[0] != "option" || fields[1] != "name" {
+		return opt, false
+	}
+
+	// Names and values may contain spaces, collect words until the next keyword.
+	key := "name"
+	var words []string
+	flush := func() {
+		value := strings.Join(words, " ")
+		switch key {
+		case "name":
+			opt.Name = value
+		case "type":
+			opt.Type = value
+		case "default":
+			opt.Default = value
+		case "min":
+			opt.Min, _ = strconv.Atoi(value)
+		case "max":
+			opt.Max, _ = strconv.Atoi(value)
+		case "var":
+			opt.Vars = append(opt.Vars, value)
+		}
+		words = nil
+	}
+	for _, field := range fields[2:] {
+		isKeyword := field == "type"
+		if key != "name" { // Only "type" terminates the option name.
+			switch field {
+			case "default", "min", "max", "var":
+				isKeyword = true
+			}
+		}
+		if isKeyword {
+			flush()
+			key = field
+			continue
+		}
+		words = append(words, field)
+	}
+	flush()
+	return opt, opt.Name != "" && opt.Type != ""
+}
@@ -0,0 +1,290 @@
+/*
+Copyright © 2020 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package uci
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The test binary doubles as an engine, its replies picked by UCI_TEST_ENGINE.
+func TestMain(m *testing.M) {
+	switch os.Getenv("UCI_TEST_ENGINE") {
+	case "":
+		os.Exit(m.Run())
+	case "exit": // Quits before the handshake.
+	case "silent": // Never answers.
+		ioutil.ReadAll(os.Stdin)
+	default:
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			switch scanner.Text() {
+			case "uci":
+				fmt.Println("id name Test Engine 1.0\nid author nobody")
+				fmt.Println("option name Skill Level type spin default 20 min 0 max 20")
+				fmt.Println("uciok")
+			case "isready":
+				if os.Getenv("UCI_TEST_ENGINE") != "unready" {
+					fmt.Println("readyok")
+				}
+			case "quit":
+				return
+			}
+		}
+	}
+}
+
+// Start the test binary as an engine of the given kind.
+func startTestEngine(t *testing.T, kind string) (*Engine, error) {
+	t.Setenv("UCI_TEST_ENGINE", kind)
+	return NewEngine(os.Args[0])
+}
+
+// Engine replying with the given lines, recording the commands sent to it.
+func scriptedEngine(replies string) (*Engine, *bytes.Buffer) {
+	sent := &bytes.Buffer{}
+	return &Engine{stdin: bufio.NewWriter(sent), stdout: bufio.NewReader(strings.NewReader(replies))}, sent
+}
+
+func TestNewEngine(t *testing.T) {
+	eng, err := startTestEngine(t, "uci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer eng.Close()
+	if eng.Name != "Test Engine 1.0" {
+		t.Errorf("engine name = %q, want %q", eng.Name, "Test Engine 1.0")
+	}
+	if opt, ok := eng.Option("skill level"); !ok || opt.Max != 20 {
+		t.Errorf("Option(\"skill level\") = %+v, %v, want the advertised Skill Level", opt, ok)
+	}
+	if _, ok := eng.Option("Hash"); ok {
+		t.Error("Option(\"Hash\") found an option not advertised")
+	}
+	if err := eng.IsReady(); err != nil {
+		t.Errorf("IsReady failed: %v", err)
+	}
+
+	if _, err := startTestEngine(t, "exit"); err == nil {
+		t.Error("NewEngine succeeded with an engine quitting before uciok")
+	}
+}
+
+func TestHandshakeTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { handshakeTimeout = timeout }(handshakeTimeout)
+	handshakeTimeout = 200 * time.Millisecond
+
+	if _, err := startTestEngine(t, "silent"); err == nil || !strings.Contains(err.Error(), "no uciok") {
+		t.Errorf("NewEngine of an engine never sending uciok failed with %v", err)
+	}
+
+	eng, err := startTestEngine(t, "unready")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer eng.Close()
+	if err := eng.IsReady(); err == nil || !strings.Contains(err.Error(), "no readyok") {
+		t.Errorf("IsReady of an engine never sending readyok failed with %v", err)
+	}
+}
+
+func TestLimitsString(t *testing.T) {
+	tests := []struct {
+		limits Limits
+		want   string
+	}{
+		{Limits{}, ""},
+		{Limits{Depth: 12}, "depth 12"},
+		{Limits{MoveTime: 1500 * time.Millisecond}, "movetime 1500"},
+		{Limits{Depth: 8, MoveTime: time.Second}, "depth 8 movetime 1000"},
+		{Limits{Depth: 8, Nodes: 100000, MoveTime: time.Second}, "depth 8 nodes 100000 movetime 1000"},
+		{Limits{WhiteTime: 5 * time.Minute, BlackTime: 4 * time.Minute, WhiteInc: 3 * time.Second, BlackInc: 3 * time.Second},
+			"wtime 300000 btime 240000 winc 3000 binc 3000"},
+		{Limits{WhiteTime: time.Minute, BlackTime: 500 * time.Millisecond}, "wtime 60000 btime 500"},
+	}
+	for _, test := range tests {
+		if got := test.limits.String(); got != test.want {
+			t.Errorf("%+v.String() = %q, want %q", test.limits, got, test.want)
+		}
+	}
+}
+
+func TestParseInfo(t *testing.T) {
+	tests := []struct {
+		line string
+		want ScoreResult
+		ok   bool
+	}{
+		{"info depth 12 seldepth 18 multipv 1 score cp 35 nodes 52340 nps 1046800 time 50 pv e2e4 e7e5 g1f3",
+			ScoreResult{Depth: 12, SelDepth: 18, MultiPV: 1, Score: 35, Nodes: 52340, NodesPerSecond: 1046800, Time: 50, BestMoves: []string{"e2e4", "e7e5", "g1f3"}}, true},
+		{"info depth 20 score cp -112 upperbound pv d7d5",
+			ScoreResult{Depth: 20, Score: -112, Upperbound: true, BestMoves: []string{"d7d5"}}, true},
+		{"info depth 20 score cp 80 lowerbound nodes 10",
+			ScoreResult{Depth: 20, Score: 80, Lowerbound: true, Nodes: 10}, true},
+		{"info depth 7 score mate -3 pv h5f7",
+			ScoreResult{Depth: 7, Score: -3, Mate: true, BestMoves: []string{"h5f7"}}, true},
+		{"info depth 5 currmove e2e4 currmovenumber 1", ScoreResult{}, false},
+		{"info string NNUE evaluation using nn.nnue", ScoreResult{}, false},
+		{"info depth 3 nodes 100", ScoreResult{}, false},   // No score.
+		{"info score cp 10 pv e2e4", ScoreResult{}, false}, // No depth.
+		{"bestmove e2e4", ScoreResult{}, false},
+		{"", ScoreResult{}, false},
+	}
+	for _, test := range tests {
+		got, ok := parseInfo(test.line)
+		if ok != test.ok || (ok && !reflect.DeepEqual(got, test.want)) {
+			t.Errorf("parseInfo(%q) = %+v, %v, want %+v, %v", test.line, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestParseOption(t *testing.T) {
+	tests := []struct {
+		line string
+		want Option
+		ok   bool
+	}{
+		{"option name Hash type spin default 16 min 1 max 33554432",
+			Option{Name: "Hash", Type: "spin", Default: "16", Min: 1, Max: 33554432}, true},
+		{"option name Skill Level type spin default 20 min 0 max 20",
+			Option{Name: "Skill Level", Type: "spin", Default: "20", Max: 20}, true},
+		{"option name UCI_LimitStrength type check default false",
+			Option{Name: "UCI_LimitStrength", Type: "check", Default: "false"}, true},
+		{"option name Style type combo default Normal var Solid var Normal var Risky Play",
+			Option{Name: "Style", Type: "combo", Default: "Normal", Vars: []string{"Solid", "Normal", "Risky Play"}}, true},
+		{"option name Debug Log File type string default",
+			Option{Name: "Debug Log File", Type: "string"}, true},
+		{"option name Clear Hash type button", Option{Name: "Clear Hash", Type: "button"}, true},
+		{"option name Hash", Option{}, false},
+		{"option type spin name Hash", Option{}, false},
+		{"id name Stockfish", Option{}, false},
+	}
+	for _, test := range tests {
+		got, ok := parseOption(test.line)
+		if ok != test.ok || (ok && !reflect.DeepEqual(got, test.want)) {
+			t.Errorf("parseOption(%q) = %+v, %v, want %+v, %v", test.line, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestGo(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  Limits
+		opts    []uint
+		replies string
+		command string
+		best    string
+		ponder  string
+		pvs     []string // First move of every result.
+	}{
+		{"depth", Limits{Depth: 2}, nil,
+			"info depth 1 score cp 10 pv d2d4\ninfo depth 2 score cp 20 pv e2e4 e7e5\nbestmove e2e4 ponder e7e5\n",
+			"go depth 2", "e2e4", "e7e5", []string{"d2d4", "e2e4"}},
+		{"highest depth only", Limits{MoveTime: time.Second}, []uint{HighestDepthOnly},
+			"info depth 1 score cp 10 pv d2d4\ninfo depth 2 score cp 20 pv e2e4\nbestmove e2e4\n",
+			"go movetime 1000", "e2e4", "", []string{"e2e4"}},
+		{"bounds left out", Limits{Nodes: 1000}, nil,
+			"info depth 3 score cp 10 upperbound pv d2d4\ninfo depth 3 score cp 20 pv c2c4\nbestmove c2c4 ponder\n",
+			"go nodes 1000", "c2c4", "", []string{"c2c4"}},
+		{"latest of a depth", Limits{}, nil,
+			"info string starting\ninfo depth 4 score cp 10 pv d2d4\ninfo depth 4 score cp 30 pv g1f3\nbestmove g1f3\n",
+			"go", "g1f3", "", []string{"g1f3"}},
+		{"no move", Limits{Depth: 1}, nil, "bestmove (none)\n", "go depth 1", "(none)", "", nil},
+	}
+	for _, test := range tests {
+		eng, sent := scriptedEngine(test.replies)
+		res, err := eng.Go(test.limits, test.opts...)
+		if err != nil {
+			t.Errorf("%s: Go failed: %v", test.name, err)
+			continue
+		}
+		var pvs []string
+		for _, r := range res.Results {
+			pvs = append(pvs, r.BestMoves[0])
+		}
+		if command := strings.TrimSpace(sent.String()); command != test.command {
+			t.Errorf("%s: sent %q, want %q", test.name, command, test.command)
+		}
+		if res.BestMove != test.best || res.Ponder != test.ponder || !reflect.DeepEqual(pvs, test.pvs) {
+			t.Errorf("%s: Go = %s ponder %q lines %v, want %s ponder %q lines %v", test.name,
+				res.BestMove, res.Ponder, pvs, test.best, test.ponder, test.pvs)
+		}
+	}
+
+	eng, _ := scriptedEngine("info depth 1 score cp 10 pv d2d4\n")
+	if _, err := eng.Go(Limits{Depth: 1}); err == nil {
+		t.Error("Go succeeded without a best move")
+	}
+}
+
+func TestPonder(t *testing.T) {
+	replies := "info depth 3 score cp 15 pv g1f3 b8c6\nbestmove g1f3 ponder b8c6\n"
+
+	eng, sent := scriptedEngine(replies)
+	if eng.Pondering() {
+		t.Error("a new engine is pondering")
+	}
+	if _, err := eng.PonderHit(); err == nil {
+		t.Error("PonderHit succeeded without pondering")
+	}
+	if err := eng.Ponder(Limits{Depth: 3}, HighestDepthOnly); err != nil || !eng.Pondering() {
+		t.Fatalf("Ponder = %v, pondering %v", err, eng.Pondering())
+	}
+	res, err := eng.PonderHit()
+	if err != nil || res.BestMove != "g1f3" || res.Ponder != "b8c6" || len(res.Results) != 1 {
+		t.Errorf("PonderHit = %+v, %v, want g1f3 ponder b8c6", res, err)
+	}
+	if eng.Pondering() || sent.String() != "go ponder depth 3\nponderhit\n" {
+		t.Errorf("sent %q, pondering %v after the ponder hit", sent.String(), eng.Pondering())
+	}
+
+	// Any other command stops the ponder search first.
+	eng, sent = scriptedEngine(replies)
+	eng.Ponder(Limits{})
+	eng.SetFEN("8/8/8/8/8/8/8/K1k5 w - - 0 1")
+	if want := "go ponder\nstop\nposition fen 8/8/8/8/8/8/8/K1k5 w - - 0 1\n"; sent.String() != want || eng.Pondering() {
+		t.Errorf("sent %q, pondering %v, want %q", sent.String(), eng.Pondering(), want)
+	}
+	if err := eng.StopPonder(); err != nil {
+		t.Errorf("StopPonder without pondering = %v", err)
+	}
+
+	eng, _ = scriptedEngine("info depth 1 score cp 0 pv e2e4\n")
+	eng.Ponder(Limits{})
+	if err := eng.StopPonder(); err == nil {
+		t.Error("StopPonder succeeded on an engine gone without a best move")
+	}
+}
+
+func TestSendOption(t *testing.T) {
+	eng, sent := scriptedEngine("")
+	eng.SendOption("Skill Level", 5)
+	eng.SetOptions(Options{MultiPV: 3, Threads: 2})
+	want := "setoption name Skill Level value 5\nsetoption name MultiPV value 3\nsetoption name Threads value 2\n" +
+		"setoption name OwnBook value false\nsetoption name Ponder value false\n"
+	if sent.String() != want {
+		t.Errorf("sent\n%s\nwant\n%s", sent.String(), want)
+	}
+}